@@ -0,0 +1,255 @@
+package pgc
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx/reflectx"
+	"github.com/sivaosorg/wrapify"
+)
+
+// BatchInsert inserts a slice of structs into the given table using multi-row INSERT ... VALUES statements.
+//
+// Columns are derived from the `db` tags of the struct fields; fields tagged with the "readonly" option
+// (e.g. `db:"id,readonly"`) are skipped so that database defaults such as serial or identity columns apply.
+// Rows are split into chunks so that no statement exceeds PostgreSQL's limit of 65535 bind parameters,
+// and each chunk is inspected as its own query.
+//
+// When more than one chunk is needed, all chunks run inside a single transaction so that the batch is
+// either fully inserted or not at all. Use Transaction.BatchInsert to take part in an existing transaction.
+//
+// Parameters:
+//   - ctx:   The context controlling cancellation and deadlines.
+//   - table: The name of the target table, optionally schema-qualified (e.g. "public.users").
+//   - rows:  A slice of structs or pointers to structs.
+//
+// Returns:
+//   - A wrapify.R instance describing the outcome, with the number of inserted rows as total.
+//
+// Example:
+//
+//	type User struct {
+//	    ID    int64  `db:"id,readonly"`
+//	    Email string `db:"email"`
+//	}
+//	response := ds.BatchInsert(ctx, "users", users)
+func (d *Datasource) BatchInsert(ctx context.Context, table string, rows any) wrapify.R {
+	_, response := BatchInsertReturning[struct{}](ctx, d, table, rows)
+	return response
+}
+
+// BatchInsert inserts a slice of structs into the given table within the transaction.
+// See Datasource.BatchInsert for details on column mapping and chunking.
+func (t *Transaction) BatchInsert(ctx context.Context, table string, rows any) wrapify.R {
+	_, response := BatchInsertReturning[struct{}](ctx, t, table, rows)
+	return response
+}
+
+// BatchInsertReturning inserts a slice of structs into the given table like BatchInsert, appending a
+// RETURNING clause to every chunk and collecting the returned rows of all chunks into a slice of T.
+//
+// T is typically a struct mapping the returned columns through `db` tags, or a scalar type when a single
+// column is returned. When no returning columns are given, nothing is collected.
+//
+// Parameters:
+//   - ctx:       The context controlling cancellation and deadlines.
+//   - exec:      The *Datasource or *Transaction to run the inserts on.
+//   - table:     The name of the target table, optionally schema-qualified.
+//   - rows:      A slice of structs or pointers to structs.
+//   - returning: The columns listed in the RETURNING clause.
+//
+// Returns:
+//   - The returned rows of all chunks, in insertion order.
+//   - A wrapify.R instance describing the outcome, with the number of inserted rows as total.
+//
+// Example:
+//
+//	ids, response := pgc.BatchInsertReturning[int64](ctx, ds, "users", users, "id")
+func BatchInsertReturning[T any](ctx context.Context, exec Executor, table string, rows any, returning ...string) ([]T, wrapify.R) {
+	ds, _, response, ok := exec.executor()
+	if !ok {
+		return nil, response
+	}
+	if isEmpty(table) {
+		response := wrapify.WrapBadRequest("Table name is required", nil).BindCause()
+		ds.dispatchEvent(EventBatchInsert, EventLevelError, response.Reply())
+		return nil, response.Reply()
+	}
+	columns, values, err := batchValues(ds.Conn().Mapper, rows)
+	if err != nil {
+		response := wrapify.WrapBadRequest(err.Error(), nil).WithErrSck(err)
+		ds.dispatchEvent(EventBatchInsert, EventLevelError, response.Reply())
+		return nil, response.Reply()
+	}
+
+	return insertRows[T](ctx, exec, "BatchInsert", EventBatchInsert, table, columns, values, "", returning)
+}
+
+// insertRows inserts the given row values into table, one multi-row INSERT statement per chunk, with the
+// optional conflict clause and RETURNING columns appended to every statement. Each chunk is inspected under
+// funcName and the overall outcome is dispatched as the given event.
+//
+// Chunks are sized so that no statement exceeds maxBindParams. When exec is a Datasource and more than one
+// chunk is needed, all chunks run inside a single transaction.
+func insertRows[T any](ctx context.Context, exec Executor, funcName string, event EventKey, table string, columns []string, values [][]any, conflict string, returning []string) ([]T, wrapify.R) {
+	ds, ext, response, ok := exec.executor()
+	if !ok {
+		return nil, response
+	}
+	chunkSize := maxBindParams / len(columns)
+	chunks := (len(values) + chunkSize - 1) / chunkSize
+
+	// Spread a multi-statement batch issued on the Datasource over a single transaction.
+	if _, isDatasource := exec.(*Datasource); isDatasource && chunks > 1 {
+		tx, err := ds.beginTx(ctx, nil)
+		if err != nil {
+			return nil, tx.Wrap()
+		}
+		out, response := insertRows[T](ctx, tx, funcName, event, table, columns, values, conflict, returning)
+		if response.IsError() {
+			tx.Rollback()
+			return nil, response
+		}
+		if response, err := tx.commit(); err != nil {
+			return nil, response
+		}
+		return out, response
+	}
+
+	var out []T
+	var affected, chunk int
+	for start := 0; start < len(values); start += chunkSize {
+		end := min(start+chunkSize, len(values))
+		chunk++
+		query, args := batchInsertQuery(table, columns, values[start:end], conflict, returning)
+
+		if len(returning) > 0 {
+			var returned []T
+			response = ds.selectContext(ctx, ext, funcName, &returned, query, args)
+			out = append(out, returned...)
+		} else {
+			_, response = ds.execContext(ctx, ext, funcName, query, args)
+		}
+		if response.IsError() {
+			cause := response.Cause()
+			response := wrapQueryErr(ctx, cause, fmt.Sprintf("An error occurred while writing chunk %d into table '%s'", chunk, table), nil).
+				WithDebuggingKV("table", table).
+				WithDebuggingKV("chunk", chunk).
+				WithDebuggingKV("affected", affected).
+				Reply()
+			ds.dispatchEvent(event, queryErrLevel(ctx, cause), response)
+			return nil, response
+		}
+		affected += response.Total()
+	}
+
+	response = wrapify.WrapOk(fmt.Sprintf("Wrote %d rows into table '%s' successfully", affected, table), nil).
+		WithDebuggingKV("table", table).
+		WithDebuggingKV("chunks", chunk).
+		WithDebuggingKV("columns", columns).
+		WithTotal(affected).
+		Reply()
+	ds.dispatchEvent(event, EventLevelSuccess, response)
+	return out, response
+}
+
+// batchInsertQuery builds a multi-row INSERT statement for the given rows, numbering the
+// placeholders sequentially, and returns it together with the flattened arguments.
+// The conflict clause (e.g. ON CONFLICT ... DO UPDATE ...) is appended verbatim when not empty.
+func batchInsertQuery(table string, columns []string, rows [][]any, conflict string, returning []string) (string, []any) {
+	var b strings.Builder
+	args := make([]any, 0, len(rows)*len(columns))
+	b.WriteString("INSERT INTO ")
+	b.WriteString(quoteIdent(table))
+	b.WriteString(" (")
+	b.WriteString(quoteIdents(columns))
+	b.WriteString(") VALUES ")
+	for i, row := range rows {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteByte('(')
+		for j, value := range row {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			args = append(args, value)
+			b.WriteByte('$')
+			b.WriteString(strconv.Itoa(len(args)))
+		}
+		b.WriteByte(')')
+	}
+	if isNotEmpty(conflict) {
+		b.WriteByte(' ')
+		b.WriteString(conflict)
+	}
+	if len(returning) > 0 {
+		b.WriteString(" RETURNING ")
+		b.WriteString(quoteIdents(returning))
+	}
+	return b.String(), args
+}
+
+// batchValues extracts the insertable columns and the per-row values of a slice of structs
+// (or pointers to structs) using the given mapper.
+//
+// Only top-level fields are considered (fields of embedded structs are promoted, as with sqlx);
+// fields tagged with the "readonly" option and nested non-scannable structs are skipped.
+// A single struct is accepted as a batch of one row.
+func batchValues(mapper *reflectx.Mapper, rows any) ([]string, [][]any, error) {
+	if rows == nil {
+		return nil, nil, fmt.Errorf("pgc: batch rows must not be nil")
+	}
+	rv := reflect.ValueOf(rows)
+	if t := reflectx.Deref(rv.Type()); t.Kind() == reflect.Struct && !isScannable(t) {
+		single := reflect.MakeSlice(reflect.SliceOf(rv.Type()), 1, 1)
+		single.Index(0).Set(rv)
+		rv = single
+	}
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil, nil, fmt.Errorf("pgc: batch rows must be a slice of structs, got %T", rows)
+	}
+	if rv.Len() == 0 {
+		return nil, nil, fmt.Errorf("pgc: batch rows must not be empty")
+	}
+	elem := reflectx.Deref(rv.Type().Elem())
+	if elem.Kind() != reflect.Struct || isScannable(elem) {
+		return nil, nil, fmt.Errorf("pgc: batch rows must be a slice of structs, got %T", rows)
+	}
+
+	var columns []string
+	var fields [][]int
+	for _, fi := range mapper.TypeMap(elem).Index {
+		if fi.Embedded || strings.Contains(fi.Path, ".") {
+			continue
+		}
+		if _, readonly := fi.Options["readonly"]; readonly {
+			continue
+		}
+		if t := reflectx.Deref(fi.Field.Type); t.Kind() == reflect.Struct && !isScannable(t) {
+			continue
+		}
+		columns = append(columns, fi.Name)
+		fields = append(fields, fi.Index)
+	}
+	if len(columns) == 0 {
+		return nil, nil, fmt.Errorf("pgc: %s has no insertable db fields", elem)
+	}
+
+	values := make([][]any, rv.Len())
+	for i := range values {
+		v := reflect.Indirect(rv.Index(i))
+		if !v.IsValid() {
+			return nil, nil, fmt.Errorf("pgc: batch row %d is nil", i)
+		}
+		row := make([]any, len(fields))
+		for j, index := range fields {
+			row[j] = reflectx.FieldByIndexesReadOnly(v, index).Interface()
+		}
+		values[i] = row
+	}
+	return columns, values, nil
+}
@@ -0,0 +1,181 @@
+package pgc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sivaosorg/wrapify"
+)
+
+// newCircuitBreaker creates a closed circuit breaker driven by policy, or returns nil when policy is nil.
+func newCircuitBreaker(policy *BreakerPolicy) *circuitBreaker {
+	if policy == nil {
+		return nil
+	}
+	return &circuitBreaker{policy: policy, state: BreakerClosed, windowStart: time.Now()}
+}
+
+// current returns the state of the circuit breaker; a nil breaker is always closed.
+func (b *circuitBreaker) current() BreakerState {
+	if b == nil {
+		return BreakerClosed
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// guard runs fn through the circuit breaker of the Datasource: it fails immediately with ErrCircuitOpen
+// while the breaker is open, and otherwise records the outcome of fn, run with ctx, to drive the breaker
+// transitions.
+func (d *Datasource) guard(ctx context.Context, fn func() error) error {
+	if err := d.allow(); err != nil {
+		return err
+	}
+	err := fn()
+	d.record(ctx, err)
+	return err
+}
+
+// allow reports whether an operation may run, returning ErrCircuitOpen when the circuit breaker rejects it.
+// An open breaker whose open timeout has elapsed turns half-open and lets a limited number of probes through.
+func (d *Datasource) allow() error {
+	b := d.breaker
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	var halfOpened bool
+	if b.state == BreakerOpen {
+		if time.Since(b.openedAt) < b.policy.OpenTimeout() {
+			b.mu.Unlock()
+			return ErrCircuitOpen
+		}
+		b.state, b.probes = BreakerHalfOpen, 0
+		halfOpened = true
+	}
+	allowed := b.state != BreakerHalfOpen || b.probes < b.policy.HalfOpenProbes()
+	if allowed && b.state == BreakerHalfOpen {
+		b.probes++
+	}
+	b.mu.Unlock()
+
+	if halfOpened {
+		response := wrapify.WrapProcessing(fmt.Sprintf("Circuit breaker of '%s' is half-open, probing the server", d.conf.ConnString()), nil).
+			WithDebuggingKV("probes", b.policy.HalfOpenProbes()).
+			WithHeader(wrapify.Processing).
+			Reply()
+		d.dispatchEvent(EventBreakerHalfOpen, EventLevelWarn, response)
+	}
+	if !allowed {
+		return ErrCircuitOpen
+	}
+	return nil
+}
+
+// record feeds the outcome of an operation run with ctx to the circuit breaker. An operation failing once
+// the deadline of ctx has expired always counts as a failure, whatever the error reported by the driver
+// (e.g. 57014 query_canceled), since a hanging server only surfaces as a timeout. Other errors the policy
+// does not classify as failures count as successes, since the server answered. Cancellations by the caller
+// are ignored.
+func (d *Datasource) record(ctx context.Context, err error) {
+	b := d.breaker
+	if b == nil {
+		return
+	}
+	expired := err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded)
+	cancelled := err != nil && (errors.Is(err, context.Canceled) || errors.Is(ctx.Err(), context.Canceled))
+	failed := expired || b.policy.IsFailure(err)
+	b.mu.Lock()
+	switch {
+	case cancelled:
+		if b.state == BreakerHalfOpen && b.probes > 0 {
+			b.probes--
+		}
+		b.mu.Unlock()
+	case b.state == BreakerHalfOpen && failed:
+		b.mu.Unlock()
+		d.openBreaker(err, "a probe operation failed while half-open")
+	case b.state == BreakerHalfOpen:
+		b.mu.Unlock()
+		d.closeBreaker("a probe operation succeeded while half-open")
+	case b.state == BreakerClosed:
+		if time.Since(b.windowStart) >= b.policy.Window() {
+			b.requests, b.failures, b.windowStart = 0, 0, time.Now()
+		}
+		b.requests++
+		if !failed {
+			b.consecutive = 0
+			b.mu.Unlock()
+			return
+		}
+		b.consecutive++
+		b.failures++
+		var reason string
+		if threshold := b.policy.FailureThreshold(); threshold > 0 && b.consecutive >= threshold {
+			reason = fmt.Sprintf("%d consecutive failures", b.consecutive)
+		} else if rate := b.policy.ErrorRate(); rate > 0 && b.requests >= b.policy.MinRequests() &&
+			float64(b.failures)/float64(b.requests) >= rate {
+			reason = fmt.Sprintf("%d of %d operations failed within %s", b.failures, b.requests, b.policy.Window())
+		}
+		b.mu.Unlock()
+		if isNotEmpty(reason) {
+			d.openBreaker(err, reason)
+		}
+	default:
+		// Operations started before the breaker opened do not affect it.
+		b.mu.Unlock()
+	}
+}
+
+// openBreaker opens the circuit breaker, unless it is already open, and dispatches an EventBreakerOpen event.
+func (d *Datasource) openBreaker(cause error, reason string) {
+	b := d.breaker
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	from := b.state
+	if from == BreakerOpen {
+		b.mu.Unlock()
+		return
+	}
+	b.state, b.openedAt, b.probes = BreakerOpen, time.Now(), 0
+	b.consecutive, b.requests, b.failures = 0, 0, 0
+	b.mu.Unlock()
+
+	response := wrapify.WrapServiceUnavailable(fmt.Sprintf("Circuit breaker of '%s' is open, failing operations fast", d.conf.ConnString()), nil).
+		WithDebuggingKV("from", from).
+		WithDebuggingKV("reason", reason).
+		WithDebuggingKV("open_timeout", b.policy.OpenTimeout().String()).
+		WithErrSck(cause).
+		WithHeader(wrapify.ServiceUnavailable).
+		Reply()
+	d.dispatchEvent(EventBreakerOpen, EventLevelError, response)
+}
+
+// closeBreaker closes the circuit breaker, unless it is already closed, and dispatches an EventBreakerClose event.
+func (d *Datasource) closeBreaker(reason string) {
+	b := d.breaker
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	from := b.state
+	if from == BreakerClosed {
+		b.mu.Unlock()
+		return
+	}
+	b.state, b.probes = BreakerClosed, 0
+	b.consecutive, b.requests, b.failures, b.windowStart = 0, 0, 0, time.Now()
+	b.mu.Unlock()
+
+	response := wrapify.WrapOk(fmt.Sprintf("Circuit breaker of '%s' is closed", d.conf.ConnString()), nil).
+		WithDebuggingKV("from", from).
+		WithDebuggingKV("reason", reason).
+		WithHeader(wrapify.OK).
+		Reply()
+	d.dispatchEvent(EventBreakerClose, EventLevelSuccess, response)
+}
@@ -1,6 +1,7 @@
 package pgc
 
 import (
+	"database/sql"
 	"fmt"
 	"strings"
 	"time"
@@ -96,6 +97,39 @@ func (c *settings) PingInterval() time.Duration {
 	return c.pingInterval
 }
 
+// StmtCacheSize returns the maximum number of prepared statements cached by the Datasource.
+// A value of zero means the statement cache is disabled.
+func (c *settings) StmtCacheSize() int {
+	return c.stmtCacheSize
+}
+
+// RetryPolicy returns the policy retrying the queries that fail with a transient error,
+// or nil when queries are not retried.
+func (c *settings) RetryPolicy() *RetryPolicy {
+	return c.retryPolicy
+}
+
+// BreakerPolicy returns the policy of the circuit breaker, or nil when the circuit breaker is disabled.
+func (c *settings) BreakerPolicy() *BreakerPolicy {
+	return c.breakerPolicy
+}
+
+// ListenerPoolConf returns the configuration of the worker pool running the notification handlers,
+// or the default one (4 workers, a queue of 1024 notifications, dropped when full) when not set.
+func (c *settings) ListenerPoolConf() PoolConf {
+	if c.listenerPoolConf != nil {
+		return *c.listenerPoolConf
+	}
+	conf := DefaultPoolConf()
+	conf.SetWorkers(defaultListenerWorkers).SetQueueSize(defaultListenerQueueSize).SetDropOnFull(true)
+	return conf
+}
+
+// CursorSecret returns the secret used to sign pagination cursors.
+func (c *settings) CursorSecret() string {
+	return c.cursorSecret
+}
+
 // IsSSLEnabled returns true if the SSL mode is enabled (i.e., not "disable"), false otherwise.
 func (c *settings) IsSSLEnabled() bool {
 	return !strings.EqualFold(c.sslmode, "disable")
@@ -243,6 +277,40 @@ func (d *Datasource) PoolStats() (eventStats, inspectStats PoolStats) {
 	return
 }
 
+// ListenerStats returns the statistics of the worker pool running the notification handlers,
+// including the number of dropped notifications. All counters are zero until the first Listen.
+func (d *Datasource) ListenerStats() PoolStats {
+	d.mu.RLock()
+	l := d.listener
+	d.mu.RUnlock()
+	if l == nil {
+		return PoolStats{}
+	}
+	return l.pool.Stats()
+}
+
+// StmtCacheStats returns the counters of the prepared statement cache.
+// When the cache is disabled, all counters are zero.
+//
+// Returns:
+//   - A StmtCacheStats snapshot with the capacity, size, hits, misses, evictions and reprepares.
+func (d *Datasource) StmtCacheStats() StmtCacheStats {
+	return d.stmtCache.stats()
+}
+
+// BreakerState returns the current state of the circuit breaker of the Datasource.
+// A Datasource without circuit breaker is always reported as BreakerClosed.
+func (d *Datasource) BreakerState() BreakerState {
+	return d.breaker.current()
+}
+
+// Queries returns the registry of named SQL queries attached to the Datasource, or nil when none is attached.
+func (d *Datasource) Queries() *QueryRegistry {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.queries
+}
+
 // getInspector returns the query inspector associated with the datasource.
 // If no inspector is set, it returns nil.
 func (d *Datasource) getInspector() QueryInspector {
@@ -379,6 +447,42 @@ func (c *settings) SetKeepalive(value bool) *settings {
 	return c
 }
 
+// SetStmtCacheSize sets the maximum number of prepared statements cached by the Datasource
+// and returns the updated Settings. A value of zero disables the statement cache.
+func (c *settings) SetStmtCacheSize(value int) *settings {
+	c.stmtCacheSize = value
+	return c
+}
+
+// SetRetryPolicy sets the policy retrying the queries that fail with a transient error
+// and returns the updated Settings. A nil policy disables retries.
+func (c *settings) SetRetryPolicy(value *RetryPolicy) *settings {
+	c.retryPolicy = value
+	return c
+}
+
+// SetBreakerPolicy sets the policy of the circuit breaker and returns the updated Settings.
+// A nil policy disables the circuit breaker.
+func (c *settings) SetBreakerPolicy(value *BreakerPolicy) *settings {
+	c.breakerPolicy = value
+	return c
+}
+
+// SetListenerPoolConf sets the configuration of the worker pool running the notification handlers
+// and returns the updated Settings. With DropOnFull disabled, no notification is dropped: the listener
+// waits for a free slot instead, and notifications queue up on its connection meanwhile.
+func (c *settings) SetListenerPoolConf(value PoolConf) *settings {
+	c.listenerPoolConf = &value
+	return c
+}
+
+// SetCursorSecret sets the secret used to sign pagination cursors and returns the updated Settings.
+// Datasources sharing the same secret accept each other's cursors.
+func (c *settings) SetCursorSecret(value string) *settings {
+	c.cursorSecret = value
+	return c
+}
+
 // SetConnectionStrings updates the connectionStrings field in the Settings structure with the specified value.
 // This field stores the complete connection string that aggregates all necessary configuration parameters
 // (e.g., host, port, user, password, database, SSL settings, etc.) into a single formatted string recognized
@@ -430,6 +534,15 @@ func (d *Datasource) SetConn(value *sqlx.DB) *Datasource {
 	return d
 }
 
+// SetQueries attaches the registry of named SQL queries used by ExecByName, SelectByName and GetByName
+// and returns the updated Datasource. A registry may be shared by several Datasources.
+func (d *Datasource) SetQueries(value *QueryRegistry) *Datasource {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.queries = value
+	return d
+}
+
 // SetState safely updates the wrapify.R instance (which holds connection status and error info)
 // of the Datasource and returns the updated Datasource.
 func (d *Datasource) SetState(value wrapify.R) *Datasource {
@@ -555,13 +668,835 @@ func (t *Transaction) Ds() *Datasource {
 }
 
 func (t *Transaction) Wrap() wrapify.R {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 	return t.wrap
 }
 
 func (t *Transaction) IsActivated() bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 	return t.active
 }
 
+// Parent returns the enclosing transaction of a nested transaction, or nil for a top-level one.
+func (t *Transaction) Parent() *Transaction {
+	return t.parent
+}
+
+// Depth returns the nesting level of the transaction (0 for a top-level transaction).
+func (t *Transaction) Depth() int {
+	return t.depth
+}
+
+// SavepointName returns the name of the savepoint backing a nested transaction,
+// or an empty string for a top-level transaction.
+func (t *Transaction) SavepointName() string {
+	return t.savepoint
+}
+
+// IsNested returns true if the transaction was created through Begin on another transaction.
+func (t *Transaction) IsNested() bool {
+	return t.parent != nil
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Getter TxOptions
+//_______________________________________________________________________
+
+// IsolationLevel returns the isolation level requested for the transaction.
+// A nil TxOptions reports sql.LevelDefault.
+func (o *TxOptions) IsolationLevel() sql.IsolationLevel {
+	if o == nil {
+		return sql.LevelDefault
+	}
+	return o.isolation
+}
+
+// IsReadOnly returns true if the transaction is started in READ ONLY mode.
+func (o *TxOptions) IsReadOnly() bool {
+	return o != nil && o.readOnly
+}
+
+// IsDeferrable returns true if the transaction is started as DEFERRABLE.
+func (o *TxOptions) IsDeferrable() bool {
+	return o != nil && o.deferrable
+}
+
+// StatementTimeout returns the per-transaction statement_timeout (zero means server default).
+func (o *TxOptions) StatementTimeout() time.Duration {
+	if o == nil {
+		return 0
+	}
+	return o.statementTimeout
+}
+
+// LockTimeout returns the per-transaction lock_timeout (zero means server default).
+func (o *TxOptions) LockTimeout() time.Duration {
+	if o == nil {
+		return 0
+	}
+	return o.lockTimeout
+}
+
+// IdleInTxSessionTimeout returns the per-transaction idle_in_transaction_session_timeout
+// (zero means server default).
+func (o *TxOptions) IdleInTxSessionTimeout() time.Duration {
+	if o == nil {
+		return 0
+	}
+	return o.idleInTxSessionTimeout
+}
+
+// sqlOptions converts the options into the driver level sql.TxOptions.
+// It returns nil when the defaults are requested, letting the driver issue a plain BEGIN.
+func (o *TxOptions) sqlOptions() *sql.TxOptions {
+	if o == nil || (o.isolation == sql.LevelDefault && !o.readOnly) {
+		return nil
+	}
+	return &sql.TxOptions{Isolation: o.isolation, ReadOnly: o.readOnly}
+}
+
+// statements returns the statements that must be executed right after BEGIN to apply the
+// options that sql.TxOptions cannot express. Timeouts are rendered in milliseconds.
+func (o *TxOptions) statements() []string {
+	if o == nil {
+		return nil
+	}
+	var stmts []string
+	if o.deferrable {
+		stmts = append(stmts, "SET TRANSACTION DEFERRABLE")
+	}
+	if o.statementTimeout > 0 {
+		stmts = append(stmts, fmt.Sprintf("SET LOCAL statement_timeout = %d", o.statementTimeout.Milliseconds()))
+	}
+	if o.lockTimeout > 0 {
+		stmts = append(stmts, fmt.Sprintf("SET LOCAL lock_timeout = %d", o.lockTimeout.Milliseconds()))
+	}
+	if o.idleInTxSessionTimeout > 0 {
+		stmts = append(stmts, fmt.Sprintf("SET LOCAL idle_in_transaction_session_timeout = %d", o.idleInTxSessionTimeout.Milliseconds()))
+	}
+	return stmts
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Setter TxOptions
+//_______________________________________________________________________
+
+// SetIsolation sets the transaction isolation level and returns the updated TxOptions.
+func (o *TxOptions) SetIsolation(value sql.IsolationLevel) *TxOptions {
+	o.isolation = value
+	return o
+}
+
+// SetReadOnly sets whether the transaction is READ ONLY and returns the updated TxOptions.
+func (o *TxOptions) SetReadOnly(value bool) *TxOptions {
+	o.readOnly = value
+	return o
+}
+
+// SetDeferrable sets whether the transaction is DEFERRABLE and returns the updated TxOptions.
+// PostgreSQL only honours DEFERRABLE for SERIALIZABLE READ ONLY transactions.
+func (o *TxOptions) SetDeferrable(value bool) *TxOptions {
+	o.deferrable = value
+	return o
+}
+
+// SetStatementTimeout sets the per-transaction statement_timeout and returns the updated TxOptions.
+func (o *TxOptions) SetStatementTimeout(value time.Duration) *TxOptions {
+	o.statementTimeout = value
+	return o
+}
+
+// SetLockTimeout sets the per-transaction lock_timeout and returns the updated TxOptions.
+func (o *TxOptions) SetLockTimeout(value time.Duration) *TxOptions {
+	o.lockTimeout = value
+	return o
+}
+
+// SetIdleInTxSessionTimeout sets the per-transaction idle_in_transaction_session_timeout
+// and returns the updated TxOptions.
+func (o *TxOptions) SetIdleInTxSessionTimeout(value time.Duration) *TxOptions {
+	o.idleInTxSessionTimeout = value
+	return o
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Getter UpsertOptions
+//_______________________________________________________________________
+
+// Constraint returns the name of the constraint used as conflict target, if set explicitly.
+func (o *UpsertOptions) Constraint() string {
+	if o == nil {
+		return ""
+	}
+	return o.constraint
+}
+
+// Include returns the only columns updated on conflict.
+func (o *UpsertOptions) Include() []string {
+	if o == nil {
+		return nil
+	}
+	return o.include
+}
+
+// Exclude returns the columns never updated on conflict.
+func (o *UpsertOptions) Exclude() []string {
+	if o == nil {
+		return nil
+	}
+	return o.exclude
+}
+
+// Where returns the SQL guard of the DO UPDATE SET clause.
+func (o *UpsertOptions) Where() string {
+	if o == nil {
+		return ""
+	}
+	return o.where
+}
+
+// IsDoNothing returns true if conflicting rows are left untouched.
+func (o *UpsertOptions) IsDoNothing() bool {
+	return o != nil && o.doNothing
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Setter UpsertOptions
+//_______________________________________________________________________
+
+// SetConstraint sets the constraint used as conflict target and returns the updated UpsertOptions.
+func (o *UpsertOptions) SetConstraint(value string) *UpsertOptions {
+	o.constraint = value
+	return o
+}
+
+// SetInclude sets the only columns updated on conflict and returns the updated UpsertOptions.
+func (o *UpsertOptions) SetInclude(values ...string) *UpsertOptions {
+	o.include = values
+	return o
+}
+
+// SetExclude sets the columns never updated on conflict and returns the updated UpsertOptions.
+func (o *UpsertOptions) SetExclude(values ...string) *UpsertOptions {
+	o.exclude = values
+	return o
+}
+
+// SetWhere sets the SQL guard of the DO UPDATE SET clause and returns the updated UpsertOptions.
+// The guard can reference the existing row through the table name and the incoming row through
+// EXCLUDED, e.g. "users.version < EXCLUDED.version".
+func (o *UpsertOptions) SetWhere(value string) *UpsertOptions {
+	o.where = value
+	return o
+}
+
+// SetDoNothing sets whether conflicting rows are left untouched and returns the updated UpsertOptions.
+func (o *UpsertOptions) SetDoNothing(value bool) *UpsertOptions {
+	o.doNothing = value
+	return o
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Getter RetryPolicy
+//_______________________________________________________________________
+
+// MaxAttempts returns the maximum number of times a query is run, including the first attempt.
+// A nil policy reports a single attempt.
+func (p *RetryPolicy) MaxAttempts() int {
+	if p == nil || p.maxAttempts < 1 {
+		return 1
+	}
+	return p.maxAttempts
+}
+
+// Backoff returns the delay before the first retry.
+func (p *RetryPolicy) Backoff() time.Duration {
+	if p == nil {
+		return 0
+	}
+	return p.backoff
+}
+
+// MaxBackoff returns the upper bound of the delay between two attempts.
+func (p *RetryPolicy) MaxBackoff() time.Duration {
+	if p == nil {
+		return 0
+	}
+	return p.maxBackoff
+}
+
+// Jitter returns the randomized fraction of each delay between two attempts.
+func (p *RetryPolicy) Jitter() float64 {
+	if p == nil {
+		return 0
+	}
+	return p.jitter
+}
+
+// IsRetryable reports whether the error is worth retrying according to the classifier of the policy
+// (IsTransientErr when none is set). A nil policy never retries.
+func (p *RetryPolicy) IsRetryable(err error) bool {
+	if p == nil || err == nil {
+		return false
+	}
+	if p.classifier != nil {
+		return p.classifier(err)
+	}
+	return IsTransientErr(err)
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Setter RetryPolicy
+//_______________________________________________________________________
+
+// SetMaxAttempts sets the maximum number of times a query is run, including the first attempt,
+// and returns the updated RetryPolicy.
+func (p *RetryPolicy) SetMaxAttempts(value int) *RetryPolicy {
+	p.maxAttempts = value
+	return p
+}
+
+// SetBackoff sets the delay before the first retry and returns the updated RetryPolicy.
+func (p *RetryPolicy) SetBackoff(value time.Duration) *RetryPolicy {
+	p.backoff = value
+	return p
+}
+
+// SetMaxBackoff sets the upper bound of the delay between two attempts and returns the updated RetryPolicy.
+func (p *RetryPolicy) SetMaxBackoff(value time.Duration) *RetryPolicy {
+	p.maxBackoff = value
+	return p
+}
+
+// SetJitter sets the randomized fraction (0 to 1) of each delay between two attempts
+// and returns the updated RetryPolicy.
+func (p *RetryPolicy) SetJitter(value float64) *RetryPolicy {
+	p.jitter = min(max(value, 0), 1)
+	return p
+}
+
+// SetClassifier sets the function reporting whether an error is worth retrying and returns the
+// updated RetryPolicy. Custom classifiers may build upon IsTransientErr.
+func (p *RetryPolicy) SetClassifier(fn func(err error) bool) *RetryPolicy {
+	p.classifier = fn
+	return p
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Getter CDCOptions
+//_______________________________________________________________________
+
+// Plugin returns the output plugin of the replication slot (CDCPluginTestDecoding by default).
+func (o *CDCOptions) Plugin() CDCPlugin {
+	if o == nil || isEmpty(string(o.plugin)) {
+		return CDCPluginTestDecoding
+	}
+	return o.plugin
+}
+
+// BatchSize returns the number of changes peeked per poll (defaultCDCBatchSize by default).
+func (o *CDCOptions) BatchSize() int {
+	if o == nil || o.batchSize <= 0 {
+		return defaultCDCBatchSize
+	}
+	return o.batchSize
+}
+
+// PollInterval returns the delay between two polls that found no pending change
+// (defaultCDCPollInterval by default).
+func (o *CDCOptions) PollInterval() time.Duration {
+	if o == nil || o.pollInterval <= 0 {
+		return defaultCDCPollInterval
+	}
+	return o.pollInterval
+}
+
+// LagThreshold returns the slot lag, in bytes of WAL, beyond which EventCDCSlotLag is reported
+// as a warning. A value of zero reports the lag as debug events only.
+func (o *CDCOptions) LagThreshold() int64 {
+	if o == nil {
+		return 0
+	}
+	return o.lagThreshold
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Setter CDCOptions
+//_______________________________________________________________________
+
+// SetPlugin sets the output plugin of the replication slot and returns the updated CDCOptions.
+func (o *CDCOptions) SetPlugin(value CDCPlugin) *CDCOptions {
+	o.plugin = value
+	return o
+}
+
+// SetBatchSize sets the number of changes peeked per poll and returns the updated CDCOptions.
+func (o *CDCOptions) SetBatchSize(value int) *CDCOptions {
+	o.batchSize = value
+	return o
+}
+
+// SetPollInterval sets the delay between two polls that found no pending change
+// and returns the updated CDCOptions.
+func (o *CDCOptions) SetPollInterval(value time.Duration) *CDCOptions {
+	o.pollInterval = value
+	return o
+}
+
+// SetLagThreshold sets the slot lag, in bytes of WAL, beyond which EventCDCSlotLag is reported
+// as a warning and returns the updated CDCOptions.
+func (o *CDCOptions) SetLagThreshold(value int64) *CDCOptions {
+	o.lagThreshold = value
+	return o
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Getter OutboxOptions
+//_______________________________________________________________________
+
+// BatchSize returns the number of messages claimed per dispatch (defaultOutboxBatchSize by default).
+func (o *OutboxOptions) BatchSize() int {
+	if o == nil || o.batchSize <= 0 {
+		return defaultOutboxBatchSize
+	}
+	return o.batchSize
+}
+
+// PollInterval returns the delay between two dispatches that found no pending message
+// (defaultOutboxPollInterval by default).
+func (o *OutboxOptions) PollInterval() time.Duration {
+	if o == nil || o.pollInterval <= 0 {
+		return defaultOutboxPollInterval
+	}
+	return o.pollInterval
+}
+
+// MaxAttempts returns the number of publish attempts after which a message is dead-lettered
+// (defaultOutboxMaxAttempts by default).
+func (o *OutboxOptions) MaxAttempts() int {
+	if o == nil || o.maxAttempts <= 0 {
+		return defaultOutboxMaxAttempts
+	}
+	return o.maxAttempts
+}
+
+// Backoff returns the delay before the first retry of a failed message (defaultOutboxBackoff by default).
+func (o *OutboxOptions) Backoff() time.Duration {
+	if o == nil || o.backoff <= 0 {
+		return defaultOutboxBackoff
+	}
+	return o.backoff
+}
+
+// MaxBackoff returns the upper bound of the delay between two publish attempts of a message
+// (defaultOutboxMaxBackoff by default).
+func (o *OutboxOptions) MaxBackoff() time.Duration {
+	if o == nil || o.maxBackoff <= 0 {
+		return defaultOutboxMaxBackoff
+	}
+	return o.maxBackoff
+}
+
+// Workers returns the number of messages of a batch published concurrently (defaultOutboxWorkers by default).
+func (o *OutboxOptions) Workers() int {
+	if o == nil || o.workers <= 0 {
+		return defaultOutboxWorkers
+	}
+	return o.workers
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Setter OutboxOptions
+//_______________________________________________________________________
+
+// SetBatchSize sets the number of messages claimed per dispatch and returns the updated OutboxOptions.
+func (o *OutboxOptions) SetBatchSize(value int) *OutboxOptions {
+	o.batchSize = value
+	return o
+}
+
+// SetPollInterval sets the delay between two dispatches that found no pending message
+// and returns the updated OutboxOptions.
+func (o *OutboxOptions) SetPollInterval(value time.Duration) *OutboxOptions {
+	o.pollInterval = value
+	return o
+}
+
+// SetMaxAttempts sets the number of publish attempts after which a message is dead-lettered
+// and returns the updated OutboxOptions.
+func (o *OutboxOptions) SetMaxAttempts(value int) *OutboxOptions {
+	o.maxAttempts = value
+	return o
+}
+
+// SetBackoff sets the delay before the first retry of a failed message and returns the updated OutboxOptions.
+func (o *OutboxOptions) SetBackoff(value time.Duration) *OutboxOptions {
+	o.backoff = value
+	return o
+}
+
+// SetMaxBackoff sets the upper bound of the delay between two publish attempts of a message
+// and returns the updated OutboxOptions.
+func (o *OutboxOptions) SetMaxBackoff(value time.Duration) *OutboxOptions {
+	o.maxBackoff = value
+	return o
+}
+
+// SetWorkers sets the number of messages of a batch published concurrently and returns the updated OutboxOptions.
+func (o *OutboxOptions) SetWorkers(value int) *OutboxOptions {
+	o.workers = value
+	return o
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Getter JobOptions
+//_______________________________________________________________________
+
+// RunAt returns the earliest time the job may run; the zero time runs it as soon as possible.
+func (o *JobOptions) RunAt() time.Time {
+	if o == nil {
+		return time.Time{}
+	}
+	return o.runAt
+}
+
+// Priority returns the priority of the job; jobs with a higher priority are claimed first.
+func (o *JobOptions) Priority() int {
+	if o == nil {
+		return 0
+	}
+	return o.priority
+}
+
+// MaxAttempts returns the number of attempts after which the job is marked as failed
+// (defaultJobMaxAttempts by default).
+func (o *JobOptions) MaxAttempts() int {
+	if o == nil || o.maxAttempts <= 0 {
+		return defaultJobMaxAttempts
+	}
+	return o.maxAttempts
+}
+
+// UniqueKey returns the uniqueness key of the job within its queue, or an empty string if not set.
+func (o *JobOptions) UniqueKey() string {
+	if o == nil {
+		return ""
+	}
+	return o.uniqueKey
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Setter JobOptions
+//_______________________________________________________________________
+
+// SetRunAt sets the earliest time the job may run and returns the updated JobOptions.
+func (o *JobOptions) SetRunAt(value time.Time) *JobOptions {
+	o.runAt = value
+	return o
+}
+
+// SetDelay sets the earliest time the job may run to now plus value and returns the updated JobOptions.
+func (o *JobOptions) SetDelay(value time.Duration) *JobOptions {
+	o.runAt = time.Now().Add(value)
+	return o
+}
+
+// SetPriority sets the priority of the job and returns the updated JobOptions.
+func (o *JobOptions) SetPriority(value int) *JobOptions {
+	o.priority = value
+	return o
+}
+
+// SetMaxAttempts sets the number of attempts after which the job is marked as failed
+// and returns the updated JobOptions.
+func (o *JobOptions) SetMaxAttempts(value int) *JobOptions {
+	o.maxAttempts = value
+	return o
+}
+
+// SetUniqueKey sets the uniqueness key of the job within its queue and returns the updated JobOptions.
+func (o *JobOptions) SetUniqueKey(value string) *JobOptions {
+	o.uniqueKey = value
+	return o
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Getter JobWorkerOptions
+//_______________________________________________________________________
+
+// Concurrency returns the number of jobs run concurrently (defaultJobConcurrency by default).
+func (o *JobWorkerOptions) Concurrency() int {
+	if o == nil || o.concurrency <= 0 {
+		return defaultJobConcurrency
+	}
+	return o.concurrency
+}
+
+// PollInterval returns the delay between two claims that found no pending job
+// (defaultJobPollInterval by default).
+func (o *JobWorkerOptions) PollInterval() time.Duration {
+	if o == nil || o.pollInterval <= 0 {
+		return defaultJobPollInterval
+	}
+	return o.pollInterval
+}
+
+// HeartbeatInterval returns the interval between two heartbeats of the running jobs
+// (defaultJobHeartbeatInterval by default).
+func (o *JobWorkerOptions) HeartbeatInterval() time.Duration {
+	if o == nil || o.heartbeatInterval <= 0 {
+		return defaultJobHeartbeatInterval
+	}
+	return o.heartbeatInterval
+}
+
+// ReclaimAfter returns how long a running job may go without heartbeat before it is reclaimed
+// (defaultJobReclaimAfter by default). It is never shorter than twice the heartbeat interval.
+func (o *JobWorkerOptions) ReclaimAfter() time.Duration {
+	if o == nil || o.reclaimAfter <= 0 {
+		return max(defaultJobReclaimAfter, 2*o.HeartbeatInterval())
+	}
+	return max(o.reclaimAfter, 2*o.HeartbeatInterval())
+}
+
+// Backoff returns the delay before the first retry of a failed job (defaultJobBackoff by default).
+func (o *JobWorkerOptions) Backoff() time.Duration {
+	if o == nil || o.backoff <= 0 {
+		return defaultJobBackoff
+	}
+	return o.backoff
+}
+
+// MaxBackoff returns the upper bound of the delay between two attempts of a job
+// (defaultJobMaxBackoff by default).
+func (o *JobWorkerOptions) MaxBackoff() time.Duration {
+	if o == nil || o.maxBackoff <= 0 {
+		return defaultJobMaxBackoff
+	}
+	return o.maxBackoff
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Setter JobWorkerOptions
+//_______________________________________________________________________
+
+// SetConcurrency sets the number of jobs run concurrently and returns the updated JobWorkerOptions.
+func (o *JobWorkerOptions) SetConcurrency(value int) *JobWorkerOptions {
+	o.concurrency = value
+	return o
+}
+
+// SetPollInterval sets the delay between two claims that found no pending job
+// and returns the updated JobWorkerOptions.
+func (o *JobWorkerOptions) SetPollInterval(value time.Duration) *JobWorkerOptions {
+	o.pollInterval = value
+	return o
+}
+
+// SetHeartbeatInterval sets the interval between two heartbeats of the running jobs
+// and returns the updated JobWorkerOptions.
+func (o *JobWorkerOptions) SetHeartbeatInterval(value time.Duration) *JobWorkerOptions {
+	o.heartbeatInterval = value
+	return o
+}
+
+// SetReclaimAfter sets how long a running job may go without heartbeat before it is reclaimed
+// and returns the updated JobWorkerOptions.
+func (o *JobWorkerOptions) SetReclaimAfter(value time.Duration) *JobWorkerOptions {
+	o.reclaimAfter = value
+	return o
+}
+
+// SetBackoff sets the delay before the first retry of a failed job and returns the updated JobWorkerOptions.
+func (o *JobWorkerOptions) SetBackoff(value time.Duration) *JobWorkerOptions {
+	o.backoff = value
+	return o
+}
+
+// SetMaxBackoff sets the upper bound of the delay between two attempts of a job
+// and returns the updated JobWorkerOptions.
+func (o *JobWorkerOptions) SetMaxBackoff(value time.Duration) *JobWorkerOptions {
+	o.maxBackoff = value
+	return o
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Getter BreakerPolicy
+//_______________________________________________________________________
+
+// FailureThreshold returns the number of consecutive failures opening the circuit breaker.
+// A value of zero disables the check.
+func (p *BreakerPolicy) FailureThreshold() int {
+	if p == nil {
+		return 0
+	}
+	return p.failureThreshold
+}
+
+// ErrorRate returns the failure rate over the window opening the circuit breaker.
+// A value of zero disables the check.
+func (p *BreakerPolicy) ErrorRate() float64 {
+	if p == nil {
+		return 0
+	}
+	return p.errorRate
+}
+
+// MinRequests returns the minimum number of operations in the window before its failure rate is evaluated.
+func (p *BreakerPolicy) MinRequests() int {
+	if p == nil {
+		return 0
+	}
+	return p.minRequests
+}
+
+// Window returns the length of the window over which the failure rate is measured,
+// or defaultBreakerWindow if not set.
+func (p *BreakerPolicy) Window() time.Duration {
+	if p == nil || p.window <= 0 {
+		return defaultBreakerWindow
+	}
+	return p.window
+}
+
+// OpenTimeout returns how long the circuit breaker stays open before turning half-open,
+// or defaultBreakerOpenTimeout if not set.
+func (p *BreakerPolicy) OpenTimeout() time.Duration {
+	if p == nil || p.openTimeout <= 0 {
+		return defaultBreakerOpenTimeout
+	}
+	return p.openTimeout
+}
+
+// HalfOpenProbes returns the number of probe operations let through while the circuit breaker
+// is half-open, at least one.
+func (p *BreakerPolicy) HalfOpenProbes() int {
+	if p == nil || p.halfOpenProbes < 1 {
+		return defaultBreakerHalfOpenProbes
+	}
+	return p.halfOpenProbes
+}
+
+// IsFailure reports whether the error counts as a failure according to the classifier of the policy
+// (IsTransientErr when none is set). Operations failing after the deadline of their context has expired
+// count as failures whatever the classifier (see Datasource.record).
+func (p *BreakerPolicy) IsFailure(err error) bool {
+	if p == nil || err == nil {
+		return false
+	}
+	if p.classifier != nil {
+		return p.classifier(err)
+	}
+	return IsTransientErr(err)
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Setter BreakerPolicy
+//_______________________________________________________________________
+
+// SetFailureThreshold sets the number of consecutive failures opening the circuit breaker
+// and returns the updated BreakerPolicy. A value of zero disables the check.
+func (p *BreakerPolicy) SetFailureThreshold(value int) *BreakerPolicy {
+	p.failureThreshold = value
+	return p
+}
+
+// SetErrorRate sets the failure rate (0 to 1) over the window opening the circuit breaker
+// and returns the updated BreakerPolicy. A value of zero disables the check.
+func (p *BreakerPolicy) SetErrorRate(value float64) *BreakerPolicy {
+	p.errorRate = min(max(value, 0), 1)
+	return p
+}
+
+// SetMinRequests sets the minimum number of operations in the window before its failure rate
+// is evaluated and returns the updated BreakerPolicy.
+func (p *BreakerPolicy) SetMinRequests(value int) *BreakerPolicy {
+	p.minRequests = value
+	return p
+}
+
+// SetWindow sets the length of the window over which the failure rate is measured
+// and returns the updated BreakerPolicy.
+func (p *BreakerPolicy) SetWindow(value time.Duration) *BreakerPolicy {
+	p.window = value
+	return p
+}
+
+// SetOpenTimeout sets how long the circuit breaker stays open before turning half-open
+// and returns the updated BreakerPolicy.
+func (p *BreakerPolicy) SetOpenTimeout(value time.Duration) *BreakerPolicy {
+	p.openTimeout = value
+	return p
+}
+
+// SetHalfOpenProbes sets the number of probe operations let through while the circuit breaker
+// is half-open and returns the updated BreakerPolicy.
+func (p *BreakerPolicy) SetHalfOpenProbes(value int) *BreakerPolicy {
+	p.halfOpenProbes = value
+	return p
+}
+
+// SetClassifier sets the function reporting whether an error counts as a failure and returns the
+// updated BreakerPolicy. Custom classifiers may build upon IsTransientErr.
+func (p *BreakerPolicy) SetClassifier(fn func(err error) bool) *BreakerPolicy {
+	p.classifier = fn
+	return p
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Getter ClusterOptions
+//_______________________________________________________________________
+
+// Policy returns the policy routing reads over the replicas (RoutePolicyRoundRobin by default).
+func (o *ClusterOptions) Policy() RoutePolicy {
+	if o == nil || isEmpty(string(o.policy)) {
+		return RoutePolicyRoundRobin
+	}
+	return o.policy
+}
+
+// MaxLag returns the replication lag beyond which a replica is removed from rotation.
+// A value of zero disables the lag check.
+func (o *ClusterOptions) MaxLag() time.Duration {
+	if o == nil {
+		return 0
+	}
+	return o.maxLag
+}
+
+// CheckInterval returns the interval between two replica probes (defaultReplicaCheckInterval by default).
+func (o *ClusterOptions) CheckInterval() time.Duration {
+	if o == nil || o.checkInterval <= 0 {
+		return defaultReplicaCheckInterval
+	}
+	return o.checkInterval
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Setter ClusterOptions
+//_______________________________________________________________________
+
+// SetPolicy sets the policy routing reads over the replicas and returns the updated ClusterOptions.
+func (o *ClusterOptions) SetPolicy(value RoutePolicy) *ClusterOptions {
+	o.policy = value
+	return o
+}
+
+// SetMaxLag sets the replication lag beyond which a replica is removed from rotation
+// and returns the updated ClusterOptions. A value of zero disables the lag check.
+func (o *ClusterOptions) SetMaxLag(value time.Duration) *ClusterOptions {
+	o.maxLag = value
+	return o
+}
+
+// SetCheckInterval sets the interval between two replica probes and returns the updated ClusterOptions.
+func (o *ClusterOptions) SetCheckInterval(value time.Duration) *ClusterOptions {
+	o.checkInterval = value
+	return o
+}
+
 //‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 // Getter Event Keys
 //_______________________________________________________________________
@@ -673,6 +1608,8 @@ func Bind(c *WConf) *settings {
 		SetKeepalive(c.KeepAlive).
 		SetConnectionStrings(c.ConnectionStrings).
 		SetOptions(c.Optional).
-		SetSchema(c.Schema)
+		SetSchema(c.Schema).
+		SetStmtCacheSize(c.StmtCacheSize).
+		SetCursorSecret(c.CursorSecret)
 	return conf
 }
@@ -0,0 +1,547 @@
+package pgc
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sivaosorg/wrapify"
+)
+
+// CDC returns a CDCReader consuming the changes of the logical replication slot named slot, creating
+// the slot with the output plugin of opts when it does not exist yet, or reusing it otherwise.
+//
+// The server must run with wal_level = logical, and the user must be allowed to use replication slots
+// (a superuser or a role with the REPLICATION attribute). The slot retains WAL until its changes are
+// consumed, so an abandoned slot should be dropped with DropSlot; watch the EventCDCSlotLag events.
+//
+// Parameters:
+//   - ctx:  The context used to look up or create the slot.
+//   - slot: The name of the replication slot (lower-case letters, digits and underscores).
+//   - opts: The reader options (nil applies the defaults).
+//
+// Returns:
+//   - A pointer to the CDCReader (nil on failure).
+//   - A wrapify.R instance describing the outcome.
+//
+// Example:
+//
+//	reader, response := ds.CDC(ctx, "audit", pgc.NewCDCOptions().SetPlugin(pgc.CDCPluginWal2JSON))
+//	if response.IsError() {
+//	    return response
+//	}
+//	response = reader.Run(ctx, func(records []pgc.CDCRecord) error {
+//	    for _, record := range records {
+//	        log.Printf("%s %s.%s %v", record.Op, record.Schema, record.Table, record.New)
+//	    }
+//	    return nil // the records are acknowledged only when nil is returned
+//	})
+func (d *Datasource) CDC(ctx context.Context, slot string, opts *CDCOptions) (*CDCReader, wrapify.R) {
+	if !d.IsConnected() {
+		return nil, d.State()
+	}
+	r := &CDCReader{ds: d, slot: slot}
+	if opts != nil {
+		r.opts = *opts
+	}
+	plugin := r.opts.Plugin()
+	if !isSlotName(slot) || (plugin != CDCPluginTestDecoding && plugin != CDCPluginWal2JSON) {
+		response := wrapify.WrapBadRequest(fmt.Sprintf("Invalid replication slot '%s' or unsupported output plugin '%s'", slot, plugin), nil).
+			WithDebuggingKV("slot", slot).
+			WithDebuggingKV("plugin", plugin).
+			BindCause().
+			Reply()
+		d.dispatchEvent(EventCDCSlot, EventLevelError, response)
+		return nil, response
+	}
+
+	query := `
+		SELECT plugin
+		FROM pg_replication_slots
+		WHERE slot_name = $1
+		AND slot_type = 'logical';
+	`
+	var plugins []string
+	// Start inspection
+	done := d.Inspect("CDC", query, slot)
+	err := d.reader("CDC").SelectContext(ctx, &plugins, query, slot)
+	// End inspection
+	done()
+
+	if err != nil {
+		response := wrapQueryErr(ctx, err, fmt.Sprintf("An error occurred while looking up the replication slot '%s'", slot), nil)
+		d.dispatchEvent(EventCDCSlot, queryErrLevel(ctx, err), response.Reply())
+		return nil, response.Reply()
+	}
+	if len(plugins) > 0 {
+		if CDCPlugin(plugins[0]) != plugin {
+			response := wrapify.WrapBadRequest(fmt.Sprintf("Replication slot '%s' uses the output plugin '%s', not '%s'", slot, plugins[0], plugin), nil).
+				WithDebuggingKV("slot", slot).
+				BindCause().
+				Reply()
+			d.dispatchEvent(EventCDCSlot, EventLevelError, response)
+			return nil, response
+		}
+		response := wrapify.WrapOk(fmt.Sprintf("Reusing the replication slot '%s'", slot), nil).
+			WithDebuggingKV("plugin", plugin).
+			Reply()
+		d.dispatchEvent(EventCDCSlot, EventLevelSuccess, response)
+		return r, response
+	}
+
+	query = "SELECT lsn::text FROM pg_create_logical_replication_slot($1, $2);"
+	var lsn string
+	conn := d.Conn()
+	err = d.retry(ctx, conn, "CDC.CreateSlot", false, func() error {
+		// Start inspection
+		done := d.Inspect("CDC.CreateSlot", query, slot, string(plugin))
+		err := conn.GetContext(ctx, &lsn, query, slot, string(plugin))
+		// End inspection
+		done()
+		return err
+	})
+	if err != nil {
+		response := wrapQueryErr(ctx, err, fmt.Sprintf("An error occurred while creating the replication slot '%s'", slot), nil)
+		d.dispatchEvent(EventCDCSlot, queryErrLevel(ctx, err), response.Reply())
+		return nil, response.Reply()
+	}
+	response := wrapify.WrapOk(fmt.Sprintf("Created the replication slot '%s'", slot), nil).
+		WithDebuggingKV("plugin", plugin).
+		WithDebuggingKV("lsn", lsn).
+		Reply()
+	d.dispatchEvent(EventCDCSlot, EventLevelSuccess, response)
+	return r, response
+}
+
+// Slot returns the name of the replication slot read by the CDCReader.
+func (r *CDCReader) Slot() string {
+	return r.slot
+}
+
+// Run polls the replication slot until ctx is done, handing every batch of decoded records over to
+// handler. A batch is acknowledged only when handler returns nil; Run stops at the first error,
+// leaving the failed batch in the slot, so that it is delivered again by the next Run or Poll.
+// When a poll finds no pending change, the next one is delayed by the poll interval.
+//
+// Returns:
+//   - A wrapify.R instance describing the outcome, with the number of handled records as total.
+//     Stopping because ctx is done is reported as a success.
+func (r *CDCReader) Run(ctx context.Context, handler func(records []CDCRecord) error) wrapify.R {
+	var total int
+	for ctx.Err() == nil {
+		peeked, handled, response := r.poll(ctx, handler)
+		if response.IsError() {
+			if ctx.Err() != nil {
+				break
+			}
+			return response
+		}
+		total += handled
+		if peeked >= r.opts.BatchSize() {
+			continue
+		}
+		timer := time.NewTimer(r.opts.PollInterval())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+	return wrapify.WrapOk(fmt.Sprintf("Stopped reading the replication slot '%s'", r.slot), nil).
+		WithDebuggingKV("reason", ctx.Err().Error()).
+		WithTotal(total).
+		Reply()
+}
+
+// Poll peeks the next batch of changes of the replication slot, hands the decoded records over to handler
+// and, when it returns nil, acknowledges them. Transaction boundaries are not handed over, and a batch that
+// only contains them is acknowledged without calling handler. After every poll the slot lag is reported
+// as an EventCDCSlotLag event.
+//
+// Returns:
+//   - The number of records handed over to handler.
+//   - A wrapify.R instance describing the outcome.
+func (r *CDCReader) Poll(ctx context.Context, handler func(records []CDCRecord) error) (int, wrapify.R) {
+	_, handled, response := r.poll(ctx, handler)
+	return handled, response
+}
+
+// poll implements Poll, additionally returning the number of peeked changes (including the
+// transaction boundaries) so that Run can tell whether more changes are pending.
+func (r *CDCReader) poll(ctx context.Context, handler func(records []CDCRecord) error) (int, int, wrapify.R) {
+	d := r.ds
+	if !d.IsConnected() {
+		return 0, 0, d.State()
+	}
+	if handler == nil {
+		response := wrapify.WrapBadRequest("Handler is required to consume changes", nil).BindCause().Reply()
+		d.dispatchEvent(EventCDCChanges, EventLevelError, response)
+		return 0, 0, response
+	}
+
+	query := fmt.Sprintf(`
+		SELECT lsn::text AS lsn, xid::text::bigint AS xid, data
+		FROM pg_logical_slot_peek_changes($1, NULL, $2%s);
+	`, r.pluginArgs())
+	var changes []cdcChange
+	// Start inspection
+	done := d.Inspect("CDC.Peek", query, r.slot, r.opts.BatchSize())
+	err := d.reader("CDC.Peek").SelectContext(ctx, &changes, query, r.slot, r.opts.BatchSize())
+	// End inspection
+	done()
+
+	if err != nil {
+		response := wrapQueryErr(ctx, err, fmt.Sprintf("An error occurred while peeking the changes of replication slot '%s'", r.slot), nil)
+		d.dispatchEvent(EventCDCChanges, queryErrLevel(ctx, err), response.Reply())
+		return 0, 0, response.Reply()
+	}
+	if len(changes) == 0 {
+		r.Lag(ctx)
+		return 0, 0, wrapify.WrapOk(fmt.Sprintf("No pending change in replication slot '%s'", r.slot), nil).Reply()
+	}
+
+	first, last := changes[0].LSN, changes[len(changes)-1].LSN
+	records := make([]CDCRecord, 0, len(changes))
+	for _, change := range changes {
+		record, ok, err := decodeCDC(r.opts.Plugin(), change)
+		if err != nil {
+			response := wrapify.WrapInternalServerError(fmt.Sprintf("Failed to decode the change at LSN %s of replication slot '%s'", change.LSN, r.slot), change).
+				WithDebuggingKV("plugin", r.opts.Plugin()).
+				WithErrSck(err).
+				Reply()
+			d.dispatchEvent(EventCDCChanges, EventLevelError, response)
+			return 0, 0, response
+		}
+		if ok {
+			records = append(records, record)
+		}
+	}
+	if len(records) > 0 {
+		if err := handler(records); err != nil {
+			response := wrapify.WrapInternalServerError(fmt.Sprintf("Handler failed, %d changes of replication slot '%s' are left unacknowledged", len(records), r.slot), nil).
+				WithDebuggingKV("from_lsn", first).
+				WithDebuggingKV("to_lsn", last).
+				WithErrSck(err).
+				Reply()
+			d.dispatchEvent(EventCDCChanges, EventLevelError, response)
+			return 0, 0, response
+		}
+	}
+
+	// The records are handled: acknowledge them even if ctx is cancelled meanwhile,
+	// so that they are not delivered twice.
+	ackCtx := context.WithoutCancel(ctx)
+	query = fmt.Sprintf("SELECT count(*) FROM pg_logical_slot_get_changes($1, $2::pg_lsn, NULL%s);", r.pluginArgs())
+	var consumed int
+	// Start inspection
+	done = d.Inspect("CDC.Ack", query, r.slot, last)
+	err = d.reader("CDC.Ack").GetContext(ackCtx, &consumed, query, r.slot, last)
+	// End inspection
+	done()
+
+	if err != nil {
+		response := wrapQueryErr(ackCtx, err, fmt.Sprintf("Handled %d changes of replication slot '%s' but failed to acknowledge them; they will be delivered again", len(records), r.slot), nil)
+		d.dispatchEvent(EventCDCChanges, queryErrLevel(ackCtx, err), response.Reply())
+		return 0, 0, response.Reply()
+	}
+	response := wrapify.WrapOk(fmt.Sprintf("Handled %d changes of replication slot '%s'", len(records), r.slot), nil).
+		WithDebuggingKV("from_lsn", first).
+		WithDebuggingKV("to_lsn", last).
+		WithDebuggingKV("consumed", consumed).
+		WithTotal(len(records)).
+		Reply()
+	d.dispatchEvent(EventCDCChanges, EventLevelSuccess, response)
+	r.Lag(ctx)
+	return len(changes), len(records), response
+}
+
+// Lag returns how far the replication slot is behind, in bytes of WAL between the current WAL position
+// and the last acknowledged change, and reports it as an EventCDCSlotLag event: a warning when it
+// exceeds the lag threshold, a debug event otherwise.
+//
+// Returns:
+//   - The slot lag in bytes.
+//   - A wrapify.R instance describing the outcome; a not found response when the slot does not exist.
+func (r *CDCReader) Lag(ctx context.Context) (int64, wrapify.R) {
+	d := r.ds
+	if !d.IsConnected() {
+		return 0, d.State()
+	}
+	query := `
+		SELECT COALESCE(pg_wal_lsn_diff(pg_current_wal_lsn(), confirmed_flush_lsn), 0)::bigint
+		FROM pg_replication_slots
+		WHERE slot_name = $1;
+	`
+	var lag int64
+	// Start inspection
+	done := d.Inspect("CDC.Lag", query, r.slot)
+	err := d.reader("CDC.Lag").GetContext(ctx, &lag, query, r.slot)
+	// End inspection
+	done()
+
+	if errors.Is(err, sql.ErrNoRows) {
+		response := wrapify.WrapNotFound(fmt.Sprintf("Replication slot '%s' not found", r.slot), nil).BindCause().Reply()
+		d.dispatchEvent(EventCDCSlotLag, EventLevelError, response)
+		return 0, response
+	}
+	if err != nil {
+		response := wrapQueryErr(ctx, err, fmt.Sprintf("An error occurred while measuring the lag of replication slot '%s'", r.slot), nil)
+		d.dispatchEvent(EventCDCSlotLag, queryErrLevel(ctx, err), response.Reply())
+		return 0, response.Reply()
+	}
+
+	level := EventLevelDebug
+	response := wrapify.WrapOk(fmt.Sprintf("Replication slot '%s' is %d bytes behind", r.slot, lag), nil).
+		WithDebuggingKV("lag_bytes", lag).
+		WithDebuggingKV("lag_threshold", r.opts.LagThreshold())
+	if threshold := r.opts.LagThreshold(); threshold > 0 && lag > threshold {
+		level = EventLevelWarn
+	}
+	d.dispatchEvent(EventCDCSlotLag, level, response.Reply())
+	return lag, response.Reply()
+}
+
+// DropSlot drops the replication slot, releasing the WAL it retains. The CDCReader must not be used afterwards.
+func (r *CDCReader) DropSlot(ctx context.Context) wrapify.R {
+	d := r.ds
+	if !d.IsConnected() {
+		return d.State()
+	}
+	query := "SELECT pg_drop_replication_slot($1);"
+	conn := d.Conn()
+	err := d.retry(ctx, conn, "CDC.DropSlot", false, func() error {
+		// Start inspection
+		done := d.Inspect("CDC.DropSlot", query, r.slot)
+		_, err := conn.ExecContext(ctx, query, r.slot)
+		// End inspection
+		done()
+		return err
+	})
+	if err != nil {
+		response := wrapQueryErr(ctx, err, fmt.Sprintf("An error occurred while dropping the replication slot '%s'", r.slot), nil)
+		d.dispatchEvent(EventCDCSlot, queryErrLevel(ctx, err), response.Reply())
+		return response.Reply()
+	}
+	response := wrapify.WrapOk(fmt.Sprintf("Dropped the replication slot '%s'", r.slot), nil).Reply()
+	d.dispatchEvent(EventCDCSlot, EventLevelSuccess, response)
+	return response
+}
+
+// pluginArgs returns the output plugin options appended to the slot function calls. Transaction
+// boundaries are kept, so that the last change of a batch is a commit and acknowledging its LSN
+// consumes the whole transaction; decodeCDC drops them.
+func (r *CDCReader) pluginArgs() string {
+	if r.opts.Plugin() == CDCPluginWal2JSON {
+		return ", 'format-version', '2', 'include-transaction', 'true'"
+	}
+	return ", 'skip-empty-xacts', '1'"
+}
+
+// isSlotName reports whether name is a valid replication slot name: 1 to 63 lower-case letters,
+// digits and underscores.
+func isSlotName(name string) bool {
+	if name == "" || len(name) > 63 {
+		return false
+	}
+	for _, r := range name {
+		if !('a' <= r && r <= 'z' || '0' <= r && r <= '9' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// decodeCDC decodes a change printed by the output plugin. It reports false, without error,
+// for the changes that are not row changes (transaction boundaries, truncations, messages).
+func decodeCDC(plugin CDCPlugin, change cdcChange) (CDCRecord, bool, error) {
+	record := CDCRecord{LSN: change.LSN, XID: change.XID}
+	if plugin == CDCPluginWal2JSON {
+		var c wal2jsonChange
+		decoder := json.NewDecoder(strings.NewReader(change.Data))
+		decoder.UseNumber()
+		if err := decoder.Decode(&c); err != nil {
+			return record, false, err
+		}
+		record.Schema, record.Table = c.Schema, c.Table
+		switch c.Action {
+		case "I":
+			record.Op, record.New = ChangeOpInsert, wal2jsonColumns(c.Columns)
+		case "U":
+			record.Op, record.Old, record.New = ChangeOpUpdate, wal2jsonColumns(c.Identity), wal2jsonColumns(c.Columns)
+		case "D":
+			record.Op, record.Old = ChangeOpDelete, wal2jsonColumns(c.Identity)
+		default:
+			return record, false, nil
+		}
+		return record, true, nil
+	}
+	return parseTestDecoding(record, change.Data)
+}
+
+// wal2jsonColumns returns the column values printed by wal2json by column name, or nil when there are none.
+func wal2jsonColumns(columns []wal2jsonColumn) map[string]any {
+	if len(columns) == 0 {
+		return nil
+	}
+	values := make(map[string]any, len(columns))
+	for _, column := range columns {
+		values[column.Name] = column.Value
+	}
+	return values
+}
+
+// parseTestDecoding parses a row change printed by test_decoding, such as
+//
+//	table public.users: UPDATE: old-key: id[integer]:1 new-tuple: id[integer]:2 name[text]:'Jane'
+//
+// into record. It reports false for the lines that are not row changes (BEGIN, COMMIT, TRUNCATE, messages).
+func parseTestDecoding(record CDCRecord, data string) (CDCRecord, bool, error) {
+	rest, ok := strings.CutPrefix(data, "table ")
+	if !ok {
+		return record, false, nil
+	}
+	schema, rest, err := cutIdent(rest)
+	if err != nil || !strings.HasPrefix(rest, ".") {
+		return record, false, fmt.Errorf("pgc: malformed test_decoding table name in %q", data)
+	}
+	table, rest, err := cutIdent(rest[1:])
+	if err != nil || !strings.HasPrefix(rest, ": ") {
+		return record, false, fmt.Errorf("pgc: malformed test_decoding table name in %q", data)
+	}
+	op, rest, ok := strings.Cut(rest[2:], ":")
+	if !ok {
+		return record, false, fmt.Errorf("pgc: malformed test_decoding change in %q", data)
+	}
+	rest = strings.TrimPrefix(rest, " ")
+	record.Schema, record.Table = schema, table
+
+	switch ChangeOp(op) {
+	case ChangeOpInsert:
+		record.Op = ChangeOpInsert
+		record.New, err = parseTestDecodingTuple(rest)
+	case ChangeOpUpdate:
+		record.Op = ChangeOpUpdate
+		if oldKey, ok := strings.CutPrefix(rest, "old-key: "); ok {
+			var newTuple string
+			oldKey, newTuple, _ = strings.Cut(oldKey, " new-tuple: ")
+			if record.Old, err = parseTestDecodingTuple(oldKey); err == nil {
+				record.New, err = parseTestDecodingTuple(newTuple)
+			}
+		} else {
+			record.New, err = parseTestDecodingTuple(rest)
+		}
+	case ChangeOpDelete:
+		record.Op = ChangeOpDelete
+		record.Old, err = parseTestDecodingTuple(rest)
+	default:
+		return record, false, nil
+	}
+	if err != nil {
+		return record, false, fmt.Errorf("pgc: malformed test_decoding tuple in %q: %w", data, err)
+	}
+	return record, true, nil
+}
+
+// parseTestDecodingTuple parses the columns printed by test_decoding, as name[type]:value pairs separated
+// by spaces, where text values are single-quoted. Numbers are returned as json.Number, booleans as bool,
+// nulls as nil and any other value as a string; unchanged TOASTed values are left out.
+func parseTestDecodingTuple(s string) (map[string]any, error) {
+	if s == "" || s == "(no-tuple-data)" {
+		return nil, nil
+	}
+	values := make(map[string]any)
+	for s != "" {
+		name, rest, err := cutIdent(s)
+		if err != nil || !strings.HasPrefix(rest, "[") {
+			return nil, fmt.Errorf("invalid column at %q", s)
+		}
+		end := strings.Index(rest, "]:")
+		if end < 0 {
+			return nil, fmt.Errorf("invalid column type at %q", rest)
+		}
+		typ := rest[1:end]
+		rest = rest[end+2:]
+
+		var raw string
+		quoted := strings.HasPrefix(rest, "'")
+		if quoted {
+			var b strings.Builder
+			i := 1
+			for ; i < len(rest); i++ {
+				if rest[i] == '\'' {
+					if i+1 < len(rest) && rest[i+1] == '\'' {
+						b.WriteByte('\'')
+						i++
+						continue
+					}
+					break
+				}
+				b.WriteByte(rest[i])
+			}
+			if i >= len(rest) {
+				return nil, fmt.Errorf("unterminated value of column %q", name)
+			}
+			raw, rest = b.String(), rest[i+1:]
+		} else {
+			raw, rest, _ = strings.Cut(rest, " ")
+		}
+		s = strings.TrimPrefix(rest, " ")
+
+		switch {
+		case !quoted && raw == "unchanged-toast-datum":
+			continue
+		case !quoted && raw == "null":
+			values[name] = nil
+		case typ == "boolean":
+			values[name] = raw == "true"
+		case isNumericType(typ):
+			if _, err := strconv.ParseFloat(raw, 64); err != nil {
+				values[name] = raw // NaN, Infinity
+			} else {
+				values[name] = json.Number(raw)
+			}
+		default:
+			values[name] = raw
+		}
+	}
+	return values, nil
+}
+
+// isNumericType reports whether the type name printed by test_decoding is a numeric type.
+func isNumericType(typ string) bool {
+	switch typ {
+	case "smallint", "integer", "bigint", "numeric", "real", "double precision", "oid":
+		return true
+	}
+	return strings.HasPrefix(typ, "numeric(")
+}
+
+// cutIdent cuts the identifier at the start of s, unquoting it when it is double-quoted,
+// and returns it together with the remainder of s.
+func cutIdent(s string) (ident, rest string, err error) {
+	if !strings.HasPrefix(s, `"`) {
+		end := strings.IndexAny(s, ".[: ")
+		if end <= 0 {
+			return "", s, fmt.Errorf("missing identifier at %q", s)
+		}
+		return s[:end], s[end:], nil
+	}
+	var b strings.Builder
+	for i := 1; i < len(s); i++ {
+		if s[i] == '"' {
+			if i+1 < len(s) && s[i+1] == '"' {
+				b.WriteByte('"')
+				i++
+				continue
+			}
+			return b.String(), s[i+1:], nil
+		}
+		b.WriteByte(s[i])
+	}
+	return "", s, fmt.Errorf("unterminated identifier at %q", s)
+}
@@ -0,0 +1,285 @@
+package pgc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sivaosorg/wrapify"
+)
+
+// NewCluster creates a Cluster over a primary Datasource and its streaming replicas, and starts probing
+// the replicas in the background.
+//
+// Every Datasource is expected to be created with NewClient and keeps its own keepalive and reconnection.
+// On top of it, each replica is probed every CheckInterval: it stays in rotation as long as it is connected,
+// answers the probe and, when MaxLag is set, its replication lag (the time elapsed since the last replayed
+// transaction, as reported by pg_last_xact_replay_timestamp) does not exceed MaxLag. A replica that has
+// replayed everything it received is considered up to date, even if the primary has been idle for long.
+//
+// The cluster reports its events through the event callback of the primary: EventClusterRoute for every
+// routing decision, EventClusterReplicaDown and EventClusterReplicaUp when a replica leaves or rejoins
+// the rotation.
+//
+// Parameters:
+//   - primary:  The primary Datasource, receiving writes and transactions.
+//   - replicas: The replica Datasources, serving reads.
+//   - opts:     The routing options (nil applies the defaults).
+//
+// Returns:
+//   - A pointer to the Cluster; call Close to stop probing and release the connections.
+//
+// Example:
+//
+//	opts := pgc.NewClusterOptions().
+//	    SetPolicy(pgc.RoutePolicyLeastLatency).
+//	    SetMaxLag(10 * time.Second)
+//	cluster := pgc.NewCluster(pgc.NewClient(*primaryConf), []*pgc.Datasource{
+//	    pgc.NewClient(*replica1Conf),
+//	    pgc.NewClient(*replica2Conf),
+//	}, opts)
+//	defer cluster.Close()
+//
+//	var users []User
+//	response := cluster.SelectCtx(ctx, &users, "SELECT * FROM users") // served by a replica
+//	tables, response := cluster.Reader().TablesCtx(ctx)                 // metadata from a replica
+func NewCluster(primary *Datasource, replicas []*Datasource, opts *ClusterOptions) *Cluster {
+	c := &Cluster{primary: primary, stop: make(chan struct{})}
+	if opts != nil {
+		c.opts = *opts
+	}
+	for _, ds := range replicas {
+		node := &clusterNode{ds: ds}
+		node.healthy.Store(ds.IsConnected())
+		c.replicas = append(c.replicas, node)
+	}
+	if len(c.replicas) > 0 {
+		go c.monitor()
+	}
+	return c
+}
+
+// Primary returns the primary Datasource.
+func (c *Cluster) Primary() *Datasource {
+	return c.primary
+}
+
+// Writer returns the Datasource receiving writes, which is always the primary.
+func (c *Cluster) Writer() *Datasource {
+	c.route(c.primary, "write", "")
+	return c.primary
+}
+
+// Reader returns the Datasource serving the next read: a healthy replica chosen according to the
+// routing policy, or the primary when no replica is in rotation. Use it for the reads that have no
+// Cluster shortcut, such as the metadata calls (e.g. cluster.Reader().ColsSpecCtx(ctx, "users")).
+func (c *Cluster) Reader() *Datasource {
+	node := c.pick()
+	if node == nil {
+		c.route(c.primary, "read", "no healthy replica, falling back to the primary")
+		return c.primary
+	}
+	c.route(node.ds, "read", "")
+	return node.ds
+}
+
+// Replicas returns the status of every replica as of its last probe.
+func (c *Cluster) Replicas() []ReplicaStatus {
+	statuses := make([]ReplicaStatus, len(c.replicas))
+	for i, node := range c.replicas {
+		statuses[i] = ReplicaStatus{
+			Name:    node.ds.conf.ConnString(),
+			Healthy: node.healthy.Load(),
+			Lag:     time.Duration(node.lag.Load()),
+			Latency: time.Duration(node.latency.Load()),
+		}
+	}
+	return statuses
+}
+
+// ExecCtx executes a statement that does not return rows on the primary. See Datasource.ExecCtx.
+func (c *Cluster) ExecCtx(ctx context.Context, query string, args ...any) (sql.Result, wrapify.R) {
+	return c.Writer().ExecCtx(ctx, query, args...)
+}
+
+// SelectCtx executes a query on a replica and scans all resulting rows into dest. See Datasource.SelectCtx.
+func (c *Cluster) SelectCtx(ctx context.Context, dest any, query string, args ...any) wrapify.R {
+	return c.Reader().SelectCtx(ctx, dest, query, args...)
+}
+
+// GetCtx executes a query on a replica and scans the first resulting row into dest. See Datasource.GetCtx.
+func (c *Cluster) GetCtx(ctx context.Context, dest any, query string, args ...any) wrapify.R {
+	return c.Reader().GetCtx(ctx, dest, query, args...)
+}
+
+// QueryxCtx executes a query on a replica and returns the resulting rows. See Datasource.QueryxCtx.
+func (c *Cluster) QueryxCtx(ctx context.Context, query string, args ...any) (*sqlx.Rows, wrapify.R) {
+	return c.Reader().QueryxCtx(ctx, query, args...)
+}
+
+// BeginTx starts a transaction on the primary. See Datasource.BeginTx.
+func (c *Cluster) BeginTx(ctx context.Context) *Transaction {
+	return c.Writer().BeginTx(ctx)
+}
+
+// BeginTxWith starts a transaction configured by opts on the primary. See Datasource.BeginTxWith.
+func (c *Cluster) BeginTxWith(ctx context.Context, opts *TxOptions) *Transaction {
+	return c.Writer().BeginTxWith(ctx, opts)
+}
+
+// WithTx runs fn within a transaction on the primary. See Datasource.WithTx.
+func (c *Cluster) WithTx(ctx context.Context, opts *TxOptions, fn func(tx *Transaction) error) wrapify.R {
+	return c.Writer().WithTx(ctx, opts, fn)
+}
+
+// Close stops probing the replicas and closes the primary and replica Datasources.
+//
+// Returns:
+//   - The errors reported while closing the connections, joined.
+func (c *Cluster) Close() error {
+	c.once.Do(func() { close(c.stop) })
+	errs := []error{c.primary.Close()}
+	for _, node := range c.replicas {
+		errs = append(errs, node.ds.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// pick returns the replica serving the next read according to the routing policy,
+// or nil when no replica is in rotation.
+func (c *Cluster) pick() *clusterNode {
+	n := len(c.replicas)
+	if n == 0 {
+		return nil
+	}
+	if c.opts.Policy() == RoutePolicyLeastLatency {
+		var best *clusterNode
+		for _, node := range c.replicas {
+			if node.usable() && (best == nil || node.latency.Load() < best.latency.Load()) {
+				best = node
+			}
+		}
+		return best
+	}
+	start := c.next.Add(1) - 1
+	for i := range uint64(n) {
+		if node := c.replicas[(start+i)%uint64(n)]; node.usable() {
+			return node
+		}
+	}
+	return nil
+}
+
+// usable reports whether the replica is in rotation and currently connected.
+func (n *clusterNode) usable() bool {
+	return n.healthy.Load() && n.ds.IsConnected()
+}
+
+// route dispatches an EventClusterRoute event describing which Datasource serves an operation.
+func (c *Cluster) route(target *Datasource, kind, reason string) {
+	level := EventLevelDebug
+	response := wrapify.WrapOk(fmt.Sprintf("Routed %s to '%s'", kind, target.conf.ConnString()), nil).
+		WithDebuggingKV("kind", kind).
+		WithDebuggingKV("policy", c.opts.Policy()).
+		WithDebuggingKV("primary", target == c.primary)
+	if isNotEmpty(reason) {
+		level = EventLevelWarn
+		response = response.WithDebuggingKV("reason", reason)
+	}
+	c.primary.dispatchEvent(EventClusterRoute, level, response.Reply())
+}
+
+// monitor probes the replicas immediately and then every CheckInterval until Close is called.
+func (c *Cluster) monitor() {
+	ticker := time.NewTicker(c.opts.CheckInterval())
+	defer ticker.Stop()
+	for {
+		for _, node := range c.replicas {
+			c.probe(node)
+		}
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probe measures the latency and replication lag of the replica and updates its rotation state,
+// dispatching EventClusterReplicaDown or EventClusterReplicaUp when the state changes.
+func (c *Cluster) probe(node *clusterNode) {
+	var cause error
+	if !node.ds.IsConnected() {
+		cause = errDatasourceNotConnected
+	} else {
+		timeout := node.ds.conf.ConnTimeout()
+		if timeout <= 0 {
+			timeout = c.opts.CheckInterval()
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		start := time.Now()
+		lag, err := node.ds.replicationLag(ctx)
+		cancel()
+		node.latency.Store(int64(time.Since(start)))
+		if err == nil {
+			node.lag.Store(int64(lag))
+			if maxLag := c.opts.MaxLag(); maxLag > 0 && lag > maxLag {
+				err = fmt.Errorf("pgc: replication lag %s exceeds %s", lag, maxLag)
+			}
+		}
+		cause = err
+	}
+
+	healthy := cause == nil
+	if node.healthy.Swap(healthy) == healthy {
+		return
+	}
+	name := node.ds.conf.ConnString()
+	if healthy {
+		response := wrapify.WrapOk(fmt.Sprintf("Replica '%s' is back in rotation", name), nil).
+			WithDebuggingKV("lag", time.Duration(node.lag.Load()).String()).
+			WithDebuggingKV("latency", time.Duration(node.latency.Load()).String()).
+			Reply()
+		c.primary.dispatchEvent(EventClusterReplicaUp, EventLevelSuccess, response)
+		return
+	}
+	response := wrapify.WrapServiceUnavailable(fmt.Sprintf("Replica '%s' is removed from rotation", name), nil).
+		WithDebuggingKV("lag", time.Duration(node.lag.Load()).String()).
+		WithDebuggingKV("max_lag", c.opts.MaxLag().String()).
+		WithErrSck(cause).
+		Reply()
+	c.primary.dispatchEvent(EventClusterReplicaDown, EventLevelWarn, response)
+}
+
+// replicationLag returns how far the replica is behind its primary: the time elapsed since the last
+// replayed transaction, or zero when the server is not in recovery, or streams from its primary and has
+// replayed all received WAL. A replica that does not stream from its primary (no WAL receiver, or the
+// primary is gone) is never considered caught up, so that its lag grows until it leaves rotation; one
+// that is behind but has not replayed any transaction yet is reported as an error.
+func (d *Datasource) replicationLag(ctx context.Context) (time.Duration, error) {
+	query := `
+		SELECT CASE
+			WHEN NOT pg_is_in_recovery() THEN 0
+			WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn()
+				AND EXISTS (SELECT 1 FROM pg_stat_wal_receiver WHERE status = 'streaming') THEN 0
+			ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+		END::float8;
+	`
+	var seconds sql.NullFloat64
+	// Start inspection
+	done := d.Inspect("Cluster.ReplicationLag", query)
+	err := d.Conn().GetContext(ctx, &seconds, query)
+	// End inspection
+	done()
+
+	if err != nil {
+		return 0, err
+	}
+	if !seconds.Valid {
+		return 0, fmt.Errorf("pgc: replica has not replayed any transaction to measure its lag from")
+	}
+	return time.Duration(seconds.Float64 * float64(time.Second)), nil
+}
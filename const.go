@@ -1,6 +1,9 @@
 package pgc
 
-import "time"
+import (
+	"errors"
+	"time"
+)
 
 // SSL modes for PostgreSQL connections.
 const (
@@ -28,19 +31,247 @@ const (
 	// defaultPingInterval defines the frequency at which the connection is pinged.
 	defaultPingInterval = 30 * time.Second
 	defaultTimeFormat   = "2006-01-02 15:04:05.000000"
+
+	// defaultSavepointPrefix is the prefix of savepoint names generated for nested transactions.
+	defaultSavepointPrefix = "pgc_sp_"
+
+	// defaultCursorPrefix is the prefix of server-side cursor names generated by Stream.
+	defaultCursorPrefix = "pgc_cur_"
+
+	// defaultCopyProgressRows is the number of copied rows between two CopyFrom progress events.
+	defaultCopyProgressRows = 10000
+
+	// maxBindParams is the maximum number of bind parameters PostgreSQL accepts in a single statement.
+	maxBindParams = 65535
+
+	// defaultStreamBatchSize is the number of rows fetched per round trip when Stream is given no batch size.
+	defaultStreamBatchSize = 1000
+
+	// defaultRegistryRetryBackoff is the initial delay between two connection attempts of an optional
+	// Registry datasource; the delay doubles after each failure, up to the datasource's ping interval.
+	defaultRegistryRetryBackoff = time.Second
+
+	// defaultReplicaCheckInterval is the interval between two Cluster replica health and lag probes.
+	defaultReplicaCheckInterval = 5 * time.Second
+
+	// defaultListenerMinReconnect is the initial delay before the listener connection is re-established;
+	// the delay doubles after each failed attempt, up to the datasource's ping interval.
+	defaultListenerMinReconnect = time.Second
+
+	// defaultListenerWorkers is the number of workers running the notification handlers.
+	defaultListenerWorkers = 4
+
+	// defaultListenerQueueSize is the number of received notifications waiting for a handler worker.
+	defaultListenerQueueSize = 1024
+
+	// notifyPayloadLimit is the size, in bytes, that a NOTIFY payload must stay below.
+	notifyPayloadLimit = 8000
+
+	// watchTriggerName is the name of the trigger installed by WatchTable on the watched tables.
+	watchTriggerName = "pgc_watch"
+
+	// watchFuncName is the name of the trigger function shared by the tables watched with WatchTable.
+	watchFuncName = "pgc_watch_notify"
+
+	// watchChannelPrefix prefixes the name of the notification channel of a watched table.
+	watchChannelPrefix = "pgc_watch_"
+
+	// defaultCDCBatchSize is the number of changes a CDCReader peeks per poll (whole transactions are always included).
+	defaultCDCBatchSize = 1000
+
+	// defaultCDCPollInterval is the delay between two polls of a CDCReader that found no pending change.
+	defaultCDCPollInterval = time.Second
+
+	// outboxTable is the name of the pgc-managed outbox table.
+	outboxTable = "pgc_outbox"
+
+	// defaultOutboxBatchSize is the number of outbox messages claimed per dispatch.
+	defaultOutboxBatchSize = 100
+
+	// defaultOutboxPollInterval is the delay between two dispatches that found no pending message.
+	defaultOutboxPollInterval = time.Second
+
+	// defaultOutboxMaxAttempts is the number of publish attempts after which a message is dead-lettered.
+	defaultOutboxMaxAttempts = 5
+
+	// defaultOutboxBackoff is the delay before the first retry of a failed message; it doubles after each attempt.
+	defaultOutboxBackoff = time.Second
+
+	// defaultOutboxMaxBackoff caps the delay between two publish attempts of a message.
+	defaultOutboxMaxBackoff = 5 * time.Minute
+
+	// defaultOutboxWorkers is the number of messages of a batch published concurrently.
+	defaultOutboxWorkers = 4
+
+	// jobsTable is the name of the pgc-managed job queue table.
+	jobsTable = "pgc_jobs"
+
+	// defaultJobMaxAttempts is the number of attempts after which a job is marked as failed.
+	defaultJobMaxAttempts = 5
+
+	// defaultJobConcurrency is the number of jobs a JobWorker runs concurrently.
+	defaultJobConcurrency = 4
+
+	// defaultJobPollInterval is the delay between two claims of a JobWorker that found no pending job.
+	defaultJobPollInterval = time.Second
+
+	// defaultJobHeartbeatInterval is the interval between two heartbeats of the jobs run by a JobWorker.
+	defaultJobHeartbeatInterval = 10 * time.Second
+
+	// defaultJobReclaimAfter is how long a running job may go without heartbeat before it is reclaimed.
+	defaultJobReclaimAfter = time.Minute
+
+	// defaultJobBackoff is the delay before the first retry of a failed job; it doubles after each attempt.
+	defaultJobBackoff = time.Second
+
+	// defaultJobMaxBackoff caps the delay between two attempts of a job.
+	defaultJobMaxBackoff = time.Hour
+
+	// defaultBreakerFailureThreshold is the number of consecutive failures opening the circuit breaker.
+	defaultBreakerFailureThreshold = 5
+
+	// defaultBreakerErrorRate is the failure rate over a window opening the circuit breaker.
+	defaultBreakerErrorRate = 0.5
+
+	// defaultBreakerMinRequests is the minimum number of operations in a window before its failure rate is evaluated.
+	defaultBreakerMinRequests = 20
+
+	// defaultBreakerWindow is the length of the window over which the failure rate is measured.
+	defaultBreakerWindow = 30 * time.Second
+
+	// defaultBreakerOpenTimeout is how long the circuit breaker stays open before letting probe operations through.
+	defaultBreakerOpenTimeout = 10 * time.Second
+
+	// defaultBreakerHalfOpenProbes is the number of probe operations let through while the circuit breaker is half-open.
+	defaultBreakerHalfOpenProbes = 1
+)
+
+// RoutePolicy values selecting the replica that serves a read routed through a Cluster.
+const (
+	RoutePolicyRoundRobin   = RoutePolicy("round_robin")   // Rotate over the healthy replicas
+	RoutePolicyLeastLatency = RoutePolicy("least_latency") // Pick the healthy replica with the lowest probe round trip
+)
+
+// CDCPlugin values naming the logical decoding output plugins understood by a CDCReader.
+const (
+	CDCPluginTestDecoding = CDCPlugin("test_decoding") // Built into PostgreSQL; values are decoded from their text output
+	CDCPluginWal2JSON     = CDCPlugin("wal2json")      // Must be installed on the server; format version 2 is used
+)
+
+// OutboxStatus values describing an outbox message.
+const (
+	OutboxStatusPending = OutboxStatus("pending") // Waiting to be published, or to be retried after a failure
+	OutboxStatusDone    = OutboxStatus("done")    // Published successfully
+	OutboxStatusDead    = OutboxStatus("dead")    // Dead-lettered after its last failed attempt
+)
+
+// JobStatus values describing a job of the job queue.
+const (
+	JobStatusPending = JobStatus("pending") // Waiting for its run time, or to be retried after a failure
+	JobStatusRunning = JobStatus("running") // Claimed by a worker
+	JobStatusDone    = JobStatus("done")    // Completed successfully
+	JobStatusFailed  = JobStatus("failed")  // Failed its last attempt
+)
+
+// ChangeOp values describing the operation of a RowChange.
+const (
+	ChangeOpInsert = ChangeOp("INSERT") // A row was inserted
+	ChangeOpUpdate = ChangeOp("UPDATE") // A row was updated
+	ChangeOpDelete = ChangeOp("DELETE") // A row was deleted
+)
+
+// ErrCategory values classifying the errors reported by PostgreSQL (see ClassifyErr).
+const (
+	ErrCategoryUnknown               = ErrCategory("unknown")                // Any other error
+	ErrCategoryUniqueViolation       = ErrCategory("unique_violation")       // 23505, reported as 409 Conflict
+	ErrCategoryForeignKeyViolation   = ErrCategory("foreign_key_violation")  // 23503, reported as 409 Conflict
+	ErrCategoryCheckViolation        = ErrCategory("check_violation")        // 23514, reported as 400 Bad Request
+	ErrCategoryNotNullViolation      = ErrCategory("not_null_violation")     // 23502, reported as 400 Bad Request
+	ErrCategoryInsufficientPrivilege = ErrCategory("insufficient_privilege") // 42501, reported as 403 Forbidden
+	ErrCategoryUndefinedTable        = ErrCategory("undefined_table")        // 42P01, reported as 404 Not Found
+	ErrCategoryQueryCanceled         = ErrCategory("query_canceled")         // 57014, reported as 408 Request Timeout
+	ErrCategorySerializationFailure  = ErrCategory("serialization_failure")  // 40001 and 40P01, reported as 409 Conflict
+	ErrCategoryConnectionFailure     = ErrCategory("connection_failure")     // Transient connection errors, reported as 503 Service Unavailable
 )
 
+// BreakerState values describing the circuit breaker of a Datasource.
+const (
+	BreakerClosed   = BreakerState("closed")    // Operations run normally
+	BreakerOpen     = BreakerState("open")      // Operations fail immediately with ErrCircuitOpen
+	BreakerHalfOpen = BreakerState("half_open") // A limited number of probe operations decide whether to close or reopen
+)
+
+// Transaction retry defaults used by WithTx.
+const (
+	// defaultTxMaxAttempts is the maximum number of times WithTx runs the transaction closure.
+	defaultTxMaxAttempts = 3
+
+	// defaultTxRetryBackoff is the initial delay before retrying a failed transaction.
+	defaultTxRetryBackoff = 50 * time.Millisecond
+
+	// defaultTxRetryMaxBackoff caps the exponential delay between transaction retries.
+	defaultTxRetryMaxBackoff = 2 * time.Second
+)
+
+// Retry policy defaults used by NewRetryPolicy.
+const (
+	// defaultRetryMaxAttempts is the maximum number of times a query is run, including the first attempt.
+	defaultRetryMaxAttempts = 3
+
+	// defaultRetryBackoff is the delay before the first retry of a query.
+	defaultRetryBackoff = 100 * time.Millisecond
+
+	// defaultRetryMaxBackoff caps the exponential delay between query retries.
+	defaultRetryMaxBackoff = 5 * time.Second
+
+	// defaultRetryJitter is the randomized fraction of each delay between query retries.
+	defaultRetryJitter = 0.5
+)
+
+// PostgreSQL SQLSTATE codes handled explicitly by the package.
+const (
+	sqlStateSerializationFailure = "40001" // serialization_failure
+	sqlStateDeadlockDetected     = "40P01" // deadlock_detected
+	sqlStateQueryCanceled        = "57014" // query_canceled
+	sqlStateFeatureNotSupported  = "0A000" // feature_not_supported (e.g. cached plan must not change result type)
+	sqlStateAdminShutdown        = "57P01" // admin_shutdown
+	sqlStateCrashShutdown        = "57P02" // crash_shutdown
+	sqlStateCannotConnectNow     = "57P03" // cannot_connect_now
+	sqlStateTooManyConnections   = "53300" // too_many_connections
+	sqlClassConnectionException  = "08"    // connection_exception class (08000, 08003, 08006, ...)
+	sqlStateUniqueViolation      = "23505" // unique_violation
+	sqlStateForeignKeyViolation  = "23503" // foreign_key_violation
+	sqlStateCheckViolation       = "23514" // check_violation
+	sqlStateNotNullViolation     = "23502" // not_null_violation
+	sqlStateInsufficientPriv     = "42501" // insufficient_privilege
+	sqlStateUndefinedTable       = "42P01" // undefined_table
+)
+
+// msgCachedPlanChanged is the message PostgreSQL reports, with SQLSTATE 0A000, when a prepared
+// statement outlives a schema change altering its result type.
+const msgCachedPlanChanged = "cached plan must not change result type"
+
+// StatusClientClosedRequest is the non-standard status code (popularised by nginx) reported
+// when a query is abandoned because its context was cancelled by the caller. Queries abandoned
+// because the context deadline expired are reported with http.StatusRequestTimeout (408).
+const StatusClientClosedRequest = 499
+
 // EventKey represents a type for event keys used in the package.
 // It is defined as a string type to provide better type safety and clarity when dealing with event keys.
 // This type can be used to define constants for various event keys that are relevant to the package's functionality.
 const (
 	// Transaction events
-	EventTxBegin           = EventKey("event_tx_begin")            // Transaction begin event
-	EventTxCommit          = EventKey("event_tx_commit")           // Transaction commit event
-	EventTxRollback        = EventKey("event_tx_rollback")         // Transaction rollback event
-	EventTxSavepointCreate = EventKey("event_tx_savepoint_create") // Transaction savepoint creation event
-	EventTxStarted         = EventKey("event_tx_started")          // Transaction started event
-	EventTxStartedAbort    = EventKey("event_tx_started_abort")    // Transaction started with abort event
+	EventTxBegin             = EventKey("event_tx_begin")              // Transaction begin event
+	EventTxCommit            = EventKey("event_tx_commit")             // Transaction commit event
+	EventTxRollback          = EventKey("event_tx_rollback")           // Transaction rollback event
+	EventTxSavepointCreate   = EventKey("event_tx_savepoint_create")   // Transaction savepoint creation event
+	EventTxSavepointRollback = EventKey("event_tx_savepoint_rollback") // Transaction rollback to savepoint event
+	EventTxSavepointRelease  = EventKey("event_tx_savepoint_release")  // Transaction savepoint release event
+	EventTxStarted           = EventKey("event_tx_started")            // Transaction started event
+	EventTxStartedAbort      = EventKey("event_tx_started_abort")      // Transaction started with abort event
+	EventTxAttempt           = EventKey("event_tx_attempt")            // Transaction closure attempt event (WithTx)
+	EventTxStream            = EventKey("event_tx_stream")             // Transaction cursor stream event
+	EventTxStreamBatch       = EventKey("event_tx_stream_batch")       // Transaction cursor stream batch progress event
 
 	// Function events
 	EventFunctionListing    = EventKey("event_function_listing")
@@ -63,6 +294,67 @@ const (
 	EventTableColsExists      = EventKey("event_table_cols_exists")
 	EventQueryInspect         = EventKey("event_query_inspect")
 
+	// Query execution events
+	EventQueryExec       = EventKey("event_query_exec")
+	EventQuerySelect     = EventKey("event_query_select")
+	EventQueryGet        = EventKey("event_query_get")
+	EventQueryRows       = EventKey("event_query_rows")
+	EventQueryNamedExec  = EventKey("event_query_named_exec")
+	EventQueryNamedQuery = EventKey("event_query_named_query")
+	EventQueryBuild      = EventKey("event_query_build")
+	EventPaginate        = EventKey("event_paginate")
+	EventQueryRegistry   = EventKey("event_query_registry")
+
+	// Bulk load events
+	EventCopyFrom     = EventKey("event_copy_from")
+	EventCopyProgress = EventKey("event_copy_progress")
+	EventBatchInsert  = EventKey("event_batch_insert")
+	EventUpsert       = EventKey("event_upsert")
+
+	// Prepared statement cache events
+	EventStmtReprepare = EventKey("event_stmt_reprepare")
+
+	// Cluster events
+	EventClusterRoute       = EventKey("event_cluster_route")
+	EventClusterReplicaDown = EventKey("event_cluster_replica_down")
+	EventClusterReplicaUp   = EventKey("event_cluster_replica_up")
+
+	// Listener events
+	EventListenerConnect    = EventKey("event_listener_connect")
+	EventListenerDisconnect = EventKey("event_listener_disconnect")
+	EventListenerReconnect  = EventKey("event_listener_reconnect")
+	EventListenerNotify     = EventKey("event_listener_notify")
+	EventListenerDrop       = EventKey("event_listener_drop")
+
+	// Table watcher events
+	EventTableWatch   = EventKey("event_table_watch")
+	EventTableUnwatch = EventKey("event_table_unwatch")
+
+	// Change data capture events
+	EventCDCSlot    = EventKey("event_cdc_slot")
+	EventCDCChanges = EventKey("event_cdc_changes")
+	EventCDCSlotLag = EventKey("event_cdc_slot_lag")
+
+	// Outbox events
+	EventOutboxSetup      = EventKey("event_outbox_setup")
+	EventOutboxEnqueue    = EventKey("event_outbox_enqueue")
+	EventOutboxDispatch   = EventKey("event_outbox_dispatch")
+	EventOutboxDeadLetter = EventKey("event_outbox_dead_letter")
+
+	// Job queue events
+	EventJobSetup     = EventKey("event_job_setup")
+	EventJobEnqueue   = EventKey("event_job_enqueue")
+	EventJobClaim     = EventKey("event_job_claim")
+	EventJobComplete  = EventKey("event_job_complete")
+	EventJobFail      = EventKey("event_job_fail")
+	EventJobHeartbeat = EventKey("event_job_heartbeat")
+	EventJobReclaim   = EventKey("event_job_reclaim")
+
+	// Circuit breaker events
+	EventBreakerOpen     = EventKey("event_breaker_open")
+	EventBreakerHalfOpen = EventKey("event_breaker_half_open")
+	EventBreakerClose    = EventKey("event_breaker_close")
+
 	// Connection events
 	EventConnOpen  = EventKey("event_conn_open")
 	EventConnClose = EventKey("event_conn_close")
@@ -80,3 +372,49 @@ const (
 	EventLevelDebug   = EventLevel("debug")   // Debug event level
 	EventLevelSuccess = EventLevel("success") // Success event level
 )
+
+// ErrStreamStop can be returned by a Stream or StreamAs callback to stop consuming rows early.
+// The stream is then closed and reported as successful.
+var ErrStreamStop = errors.New("pgc: stream stopped")
+
+// ErrCircuitOpen is returned, wrapped in a service unavailable response, by the operations rejected
+// because the circuit breaker of the Datasource is open.
+var ErrCircuitOpen = errors.New("pgc: circuit breaker is open")
+
+// Sentinel errors used internally to report invalid states.
+var (
+	errDatasourceNotConnected = errors.New("pgc: datasource is not connected")
+	errTxNotActive            = errors.New("pgc: transaction is not active")
+	errTxOpenChildren         = errors.New("pgc: transaction has open nested transactions")
+)
+
+// sqlKeywords lists the PostgreSQL keywords that quote_ident always quotes: the reserved,
+// type/function-name and column-name keywords. Unreserved keywords can be used as plain identifiers.
+var sqlKeywords = map[string]struct{}{
+	"all": {}, "analyse": {}, "analyze": {}, "and": {}, "any": {}, "array": {}, "as": {}, "asc": {},
+	"asymmetric": {}, "authorization": {}, "between": {}, "bigint": {}, "binary": {}, "bit": {},
+	"boolean": {}, "both": {}, "case": {}, "cast": {}, "char": {}, "character": {}, "check": {},
+	"coalesce": {}, "collate": {}, "collation": {}, "column": {}, "concurrently": {}, "constraint": {},
+	"create": {}, "cross": {}, "current_catalog": {}, "current_date": {}, "current_role": {},
+	"current_schema": {}, "current_time": {}, "current_timestamp": {}, "current_user": {}, "dec": {},
+	"decimal": {}, "default": {}, "deferrable": {}, "desc": {}, "distinct": {}, "do": {}, "else": {},
+	"end": {}, "except": {}, "exists": {}, "extract": {}, "false": {}, "fetch": {}, "float": {},
+	"for": {}, "foreign": {}, "freeze": {}, "from": {}, "full": {}, "grant": {}, "greatest": {},
+	"group": {}, "grouping": {}, "having": {}, "ilike": {}, "in": {}, "initially": {}, "inner": {},
+	"inout": {}, "int": {}, "integer": {}, "intersect": {}, "interval": {}, "into": {}, "is": {},
+	"isnull": {}, "join": {}, "json": {}, "json_array": {}, "json_arrayagg": {}, "json_exists": {},
+	"json_object": {}, "json_objectagg": {}, "json_query": {}, "json_scalar": {}, "json_serialize": {},
+	"json_table": {}, "json_value": {}, "lateral": {}, "leading": {}, "least": {}, "left": {},
+	"like": {}, "limit": {}, "localtime": {}, "localtimestamp": {}, "merge_action": {}, "national": {},
+	"natural": {}, "nchar": {}, "none": {}, "normalize": {}, "not": {}, "notnull": {}, "null": {},
+	"nullif": {}, "numeric": {}, "offset": {}, "on": {}, "only": {}, "or": {}, "order": {}, "out": {},
+	"outer": {}, "overlaps": {}, "overlay": {}, "placing": {}, "position": {}, "precision": {},
+	"primary": {}, "real": {}, "references": {}, "returning": {}, "right": {}, "row": {}, "select": {},
+	"session_user": {}, "setof": {}, "similar": {}, "smallint": {}, "some": {}, "substring": {},
+	"symmetric": {}, "system_user": {}, "table": {}, "tablesample": {}, "then": {}, "time": {},
+	"timestamp": {}, "to": {}, "trailing": {}, "treat": {}, "trim": {}, "true": {}, "union": {},
+	"unique": {}, "user": {}, "using": {}, "values": {}, "varchar": {}, "variadic": {}, "verbose": {},
+	"when": {}, "where": {}, "window": {}, "with": {}, "xmlattributes": {}, "xmlconcat": {},
+	"xmlelement": {}, "xmlexists": {}, "xmlforest": {}, "xmlnamespaces": {}, "xmlparse": {},
+	"xmlpi": {}, "xmlroot": {}, "xmlserialize": {}, "xmltable": {},
+}
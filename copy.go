@@ -0,0 +1,279 @@
+package pgc
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx/reflectx"
+	"github.com/lib/pq"
+	"github.com/sivaosorg/wrapify"
+)
+
+// CopyFrom bulk-loads the rows supplied by source into the given table using the PostgreSQL COPY protocol.
+//
+// Before the copy starts, the requested columns are validated against the table definition in its schema
+// (see ColsExistsInCtx); unknown columns are rejected with a bad request response. A table name without
+// schema refers to the table of the current schema (current_schema()). The copy then runs inside a
+// dedicated transaction through pq.CopyIn: either every row is loaded and the transaction is committed,
+// or the transaction is rolled back and nothing is loaded.
+//
+// Every 10,000 rows an EventCopyProgress event is dispatched carrying the "table", "rows" and "elapsed"
+// debugging key-values, and a final EventCopyFrom event reports the outcome of the whole copy.
+//
+// Parameters:
+//   - ctx:     The context controlling cancellation and deadlines.
+//   - table:   The name of the target table.
+//   - columns: The columns to load, in the order expected by the rows of the source.
+//   - source:  The CopySource supplying the rows (see CopyFromSlice, CopyFromChan and CopyFromFunc).
+//
+// Returns:
+//   - A wrapify.R instance describing the outcome, with the number of copied rows as total.
+//
+// Example:
+//
+//	response := ds.CopyFrom(ctx, "events", []string{"id", "kind", "payload"}, pgc.CopyFromSlice(events))
+//	if response.IsError() {
+//	    log.Println(response.Error())
+//	}
+func (d *Datasource) CopyFrom(ctx context.Context, table string, columns []string, source CopySource) wrapify.R {
+	if !d.IsConnected() {
+		return d.State()
+	}
+	if isEmpty(table) {
+		response := wrapify.WrapBadRequest("Table name is required", nil).BindCause()
+		d.dispatchEvent(EventCopyFrom, EventLevelError, response.Reply())
+		return response.Reply()
+	}
+	if len(columns) == 0 {
+		response := wrapify.WrapBadRequest("At least one column is required", nil).BindCause()
+		d.dispatchEvent(EventCopyFrom, EventLevelError, response.Reply())
+		return response.Reply()
+	}
+	if source == nil {
+		response := wrapify.WrapBadRequest("Copy source is required", nil).BindCause()
+		d.dispatchEvent(EventCopyFrom, EventLevelError, response.Reply())
+		return response.Reply()
+	}
+
+	schema, name := "", table
+	if i := strings.LastIndex(table, "."); i >= 0 {
+		schema, name = table[:i], table[i+1:]
+	}
+	if isEmpty(schema) {
+		query := "SELECT COALESCE(current_schema(), 'public');"
+		// Start inspection
+		done := d.Inspect("CopyFrom-schema", query)
+		err := d.reader("CopyFrom-schema").GetContext(ctx, &schema, query)
+		// End inspection
+		done()
+
+		if err != nil {
+			response := wrapQueryErr(ctx, err, fmt.Sprintf("An error occurred while resolving the schema of table '%s'", table), nil)
+			d.dispatchEvent(EventCopyFrom, queryErrLevel(ctx, err), response.Reply())
+			return response.Reply()
+		}
+	}
+	ces, response := d.ColsExistsInCtx(ctx, schema, []string{name}, columns)
+	if response.IsError() {
+		return response
+	}
+	var missing []string
+	for _, col := range ces.Stats.MissingCols {
+		missing = append(missing, col.ColumnName)
+	}
+	if len(missing) > 0 {
+		response := wrapify.WrapBadRequest(fmt.Sprintf("Unknown columns %v for table '%s'", missing, table), nil).
+			WithDebuggingKV("schema", schema).
+			WithDebuggingKV("missing_columns", missing).
+			BindCause()
+		d.dispatchEvent(EventCopyFrom, EventLevelError, response.Reply())
+		return response.Reply()
+	}
+
+	tx, err := d.beginTx(ctx, nil)
+	if err != nil {
+		return tx.Wrap()
+	}
+	query := pq.CopyInSchema(schema, name, columns...)
+
+	start := time.Now()
+	// Start inspection
+	done := d.Inspect("CopyFrom", query)
+	rows, err := d.copyRows(ctx, tx, query, table, columns, source, start)
+	// End inspection
+	done()
+
+	if err != nil {
+		tx.Rollback()
+		response := wrapQueryErr(ctx, err, fmt.Sprintf("An error occurred while copying rows into table '%s'", table), nil).
+			WithDebuggingKV("table", table).
+			WithDebuggingKV("rows", rows).
+			WithDebuggingKV("elapsed", time.Since(start).String()).
+			Reply()
+		d.dispatchEvent(EventCopyFrom, queryErrLevel(ctx, err), response)
+		return response
+	}
+	if response, err := tx.commit(); err != nil {
+		return response
+	}
+
+	response = wrapify.WrapOk(fmt.Sprintf("Copied %d rows into table '%s' successfully", rows, table), nil).
+		WithDebuggingKV("table", table).
+		WithDebuggingKV("rows", rows).
+		WithDebuggingKV("elapsed", time.Since(start).String()).
+		WithTotal(rows).
+		Reply()
+	d.dispatchEvent(EventCopyFrom, EventLevelSuccess, response)
+	return response
+}
+
+// CopyFromSlice returns a CopySource reading the rows of the given slice in order.
+func CopyFromSlice[T any](items []T) CopySource {
+	return &copySliceSource[T]{items: items}
+}
+
+// CopyFromChan returns a CopySource receiving rows from ch until it is closed.
+// Waiting for the next row is interrupted when the copy context is done.
+func CopyFromChan[T any](ch <-chan T) CopySource {
+	return &copyChanSource[T]{ch: ch}
+}
+
+// CopyFromFunc returns a CopySource pulling rows from fn, which reports ok=false once exhausted.
+// An error returned by fn aborts the copy and rolls it back.
+//
+// Example:
+//
+//	scanner := bufio.NewScanner(file)
+//	source := pgc.CopyFromFunc(func(ctx context.Context) (any, bool, error) {
+//	    if !scanner.Scan() {
+//	        return nil, false, scanner.Err()
+//	    }
+//	    return strings.Split(scanner.Text(), ","), true, nil
+//	})
+func CopyFromFunc(fn func(ctx context.Context) (row any, ok bool, err error)) CopySource {
+	return copyFuncSource(fn)
+}
+
+// Next implements CopySource.
+func (s *copySliceSource[T]) Next(ctx context.Context) (any, bool, error) {
+	if s.pos >= len(s.items) {
+		return nil, false, nil
+	}
+	item := s.items[s.pos]
+	s.pos++
+	return item, true, nil
+}
+
+// Next implements CopySource.
+func (s *copyChanSource[T]) Next(ctx context.Context) (any, bool, error) {
+	select {
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	case item, ok := <-s.ch:
+		if !ok {
+			return nil, false, nil
+		}
+		return item, true, nil
+	}
+}
+
+// Next implements CopySource.
+func (f copyFuncSource) Next(ctx context.Context) (any, bool, error) {
+	return f(ctx)
+}
+
+// copyRows streams the rows of source into the prepared COPY statement and flushes it.
+// It dispatches an EventCopyProgress event every defaultCopyProgressRows rows and returns
+// the number of rows copied so far, even on failure.
+func (d *Datasource) copyRows(ctx context.Context, tx *Transaction, query, table string, columns []string, source CopySource, start time.Time) (int, error) {
+	stmt, err := tx.tx.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	mapper := d.Conn().Mapper
+	rows := 0
+	for {
+		row, ok, err := source.Next(ctx)
+		if err != nil {
+			return rows, err
+		}
+		if !ok {
+			break
+		}
+		values, err := copyValues(mapper, row, columns)
+		if err != nil {
+			return rows, err
+		}
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			return rows, err
+		}
+		rows++
+		if rows%defaultCopyProgressRows == 0 {
+			progress := wrapify.WrapProcessing(fmt.Sprintf("Copied %d rows into table '%s'", rows, table), nil).
+				WithDebuggingKV("table", table).
+				WithDebuggingKV("rows", rows).
+				WithDebuggingKV("elapsed", time.Since(start).String()).
+				WithTotal(rows).
+				Reply()
+			d.dispatchEvent(EventCopyProgress, EventLevelInfo, progress)
+		}
+	}
+	// An Exec without arguments flushes the buffered rows and completes the COPY.
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return rows, err
+	}
+	return rows, nil
+}
+
+// copyValues converts a row supplied by a CopySource into the values of the copied columns.
+//
+// Structs (or pointers to structs) are mapped through the given mapper (honouring `db` tags),
+// maps are looked up by column name, []any rows are used as-is and any other value is treated
+// as the single value of a one-column copy.
+func copyValues(mapper *reflectx.Mapper, row any, columns []string) ([]any, error) {
+	switch r := row.(type) {
+	case []any:
+		if len(r) != len(columns) {
+			return nil, fmt.Errorf("pgc: copy row has %d values, expected %d", len(r), len(columns))
+		}
+		return r, nil
+	case []string:
+		values := make([]any, len(r))
+		for i, v := range r {
+			values[i] = v
+		}
+		return copyValues(mapper, values, columns)
+	case map[string]any:
+		values := make([]any, len(columns))
+		for i, column := range columns {
+			values[i] = r[column]
+		}
+		return values, nil
+	}
+
+	v := reflect.ValueOf(row)
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct || isScannable(v.Type()) {
+		if len(columns) != 1 {
+			return nil, fmt.Errorf("pgc: unsupported copy row type %T for %d columns", row, len(columns))
+		}
+		return []any{row}, nil
+	}
+
+	values := make([]any, len(columns))
+	traversals := mapper.TraversalsByName(v.Type(), columns)
+	for i, traversal := range traversals {
+		if len(traversal) == 0 {
+			return nil, fmt.Errorf("pgc: column '%s' is not mapped by any field of %s", columns[i], v.Type())
+		}
+		values[i] = reflectx.FieldByIndexesReadOnly(v, traversal).Interface()
+	}
+	return values, nil
+}
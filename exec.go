@@ -0,0 +1,479 @@
+package pgc
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sivaosorg/wrapify"
+)
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Inspected Execution Datasource
+// Thin wrappers around sqlx that time every query through Inspect,
+// dispatch success and failure events, and report outcomes as wrapify.R.
+//_______________________________________________________________________
+
+// Exec executes a query that does not return rows (INSERT, UPDATE, DELETE, DDL, ...).
+// It is equivalent to ExecCtx with context.Background().
+func (d *Datasource) Exec(query string, args ...any) (sql.Result, wrapify.R) {
+	return d.ExecCtx(context.Background(), query, args...)
+}
+
+// ExecCtx executes a query that does not return rows using the provided context.
+//
+// The query is timed through Inspect, so it reaches the configured QueryInspector and LastInspect,
+// and an EventQueryExec event is dispatched with the outcome. On success, the number of affected
+// rows is reported as the response total.
+//
+// Parameters:
+//   - ctx:   The context controlling cancellation and deadlines.
+//   - query: The SQL statement with PostgreSQL-style placeholders ($1, $2, ...).
+//   - args:  The values bound to the placeholders.
+//
+// Returns:
+//   - The sql.Result reported by the driver (nil on failure).
+//   - A wrapify.R instance describing the outcome.
+func (d *Datasource) ExecCtx(ctx context.Context, query string, args ...any) (sql.Result, wrapify.R) {
+	if !d.IsConnected() {
+		return nil, d.State()
+	}
+	return d.execContext(ctx, d.ext(), "Exec", query, args)
+}
+
+// Select executes a query and scans all resulting rows into dest, which must be a pointer to a slice.
+// It is equivalent to SelectCtx with context.Background().
+func (d *Datasource) Select(dest any, query string, args ...any) wrapify.R {
+	return d.SelectCtx(context.Background(), dest, query, args...)
+}
+
+// SelectCtx executes a query using the provided context and scans all resulting rows into dest,
+// which must be a pointer to a slice of structs or scannable values.
+//
+// The query is timed through Inspect and an EventQuerySelect event is dispatched with the outcome.
+// On success, the number of scanned rows is reported as the response total.
+//
+// Parameters:
+//   - ctx:   The context controlling cancellation and deadlines.
+//   - dest:  A pointer to the destination slice.
+//   - query: The SQL query with PostgreSQL-style placeholders.
+//   - args:  The values bound to the placeholders.
+//
+// Returns:
+//   - A wrapify.R instance describing the outcome.
+func (d *Datasource) SelectCtx(ctx context.Context, dest any, query string, args ...any) wrapify.R {
+	if !d.IsConnected() {
+		return d.State()
+	}
+	return d.selectContext(ctx, d.ext(), "Select", dest, query, args)
+}
+
+// Get executes a query that is expected to return at most one row and scans it into dest.
+// It is equivalent to GetCtx with context.Background().
+func (d *Datasource) Get(dest any, query string, args ...any) wrapify.R {
+	return d.GetCtx(context.Background(), dest, query, args...)
+}
+
+// GetCtx executes a query using the provided context and scans the first resulting row into dest,
+// which must be a pointer to a struct or a scannable value.
+//
+// The query is timed through Inspect and an EventQueryGet event is dispatched with the outcome.
+// When the query returns no rows, a not found response is returned.
+//
+// Parameters:
+//   - ctx:   The context controlling cancellation and deadlines.
+//   - dest:  A pointer to the destination value.
+//   - query: The SQL query with PostgreSQL-style placeholders.
+//   - args:  The values bound to the placeholders.
+//
+// Returns:
+//   - A wrapify.R instance describing the outcome.
+func (d *Datasource) GetCtx(ctx context.Context, dest any, query string, args ...any) wrapify.R {
+	if !d.IsConnected() {
+		return d.State()
+	}
+	return d.getContext(ctx, d.ext(), "Get", dest, query, args)
+}
+
+// Queryx executes a query and returns the resulting *sqlx.Rows.
+// It is equivalent to QueryxCtx with context.Background().
+func (d *Datasource) Queryx(query string, args ...any) (*sqlx.Rows, wrapify.R) {
+	return d.QueryxCtx(context.Background(), query, args...)
+}
+
+// QueryxCtx executes a query using the provided context and returns the resulting *sqlx.Rows.
+//
+// The query is timed through Inspect and an EventQueryRows event is dispatched with the outcome.
+// The caller is responsible for closing the returned rows.
+//
+// Parameters:
+//   - ctx:   The context controlling cancellation and deadlines.
+//   - query: The SQL query with PostgreSQL-style placeholders.
+//   - args:  The values bound to the placeholders.
+//
+// Returns:
+//   - The resulting rows (nil on failure).
+//   - A wrapify.R instance describing the outcome.
+func (d *Datasource) QueryxCtx(ctx context.Context, query string, args ...any) (*sqlx.Rows, wrapify.R) {
+	if !d.IsConnected() {
+		return nil, d.State()
+	}
+	return d.queryxContext(ctx, d.ext(), "Queryx", query, args)
+}
+
+// NamedExec executes a query using named parameters (:name) bound from a struct or map.
+// It is equivalent to NamedExecCtx with context.Background().
+func (d *Datasource) NamedExec(query string, arg any) (sql.Result, wrapify.R) {
+	return d.NamedExecCtx(context.Background(), query, arg)
+}
+
+// NamedExecCtx executes a query using named parameters (:name) bound from a struct or map,
+// using the provided context.
+//
+// The query is timed through Inspect and an EventQueryNamedExec event is dispatched with the outcome.
+//
+// Parameters:
+//   - ctx:   The context controlling cancellation and deadlines.
+//   - query: The SQL statement with named parameters.
+//   - arg:   A struct (using db tags) or a map[string]any providing the parameter values.
+//
+// Returns:
+//   - The sql.Result reported by the driver (nil on failure).
+//   - A wrapify.R instance describing the outcome.
+func (d *Datasource) NamedExecCtx(ctx context.Context, query string, arg any) (sql.Result, wrapify.R) {
+	if !d.IsConnected() {
+		return nil, d.State()
+	}
+	return d.namedExecContext(ctx, d.ext(), "NamedExec", query, arg)
+}
+
+// NamedQuery executes a query using named parameters (:name) and returns the resulting *sqlx.Rows.
+// It is equivalent to NamedQueryCtx with context.Background().
+func (d *Datasource) NamedQuery(query string, arg any) (*sqlx.Rows, wrapify.R) {
+	return d.NamedQueryCtx(context.Background(), query, arg)
+}
+
+// NamedQueryCtx executes a query using named parameters (:name) bound from a struct or map,
+// using the provided context, and returns the resulting *sqlx.Rows.
+//
+// The query is timed through Inspect and an EventQueryNamedQuery event is dispatched with the outcome.
+// The caller is responsible for closing the returned rows.
+//
+// Parameters:
+//   - ctx:   The context controlling cancellation and deadlines.
+//   - query: The SQL query with named parameters.
+//   - arg:   A struct (using db tags) or a map[string]any providing the parameter values.
+//
+// Returns:
+//   - The resulting rows (nil on failure).
+//   - A wrapify.R instance describing the outcome.
+func (d *Datasource) NamedQueryCtx(ctx context.Context, query string, arg any) (*sqlx.Rows, wrapify.R) {
+	if !d.IsConnected() {
+		return nil, d.State()
+	}
+	return d.namedQueryContext(ctx, d.ext(), "NamedQuery", query, arg)
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Inspected Execution Transaction
+//_______________________________________________________________________
+
+// Exec executes a query that does not return rows within the transaction.
+// It is equivalent to ExecCtx with context.Background().
+func (t *Transaction) Exec(query string, args ...any) (sql.Result, wrapify.R) {
+	return t.ExecCtx(context.Background(), query, args...)
+}
+
+// ExecCtx executes a query that does not return rows within the transaction using the provided context.
+// See Datasource.ExecCtx for details on inspection and events.
+func (t *Transaction) ExecCtx(ctx context.Context, query string, args ...any) (sql.Result, wrapify.R) {
+	tx, response, ok := t.session()
+	if !ok {
+		return nil, response
+	}
+	return t.ds.execContext(ctx, tx, "Tx.Exec", query, args)
+}
+
+// Select executes a query within the transaction and scans all resulting rows into dest.
+// It is equivalent to SelectCtx with context.Background().
+func (t *Transaction) Select(dest any, query string, args ...any) wrapify.R {
+	return t.SelectCtx(context.Background(), dest, query, args...)
+}
+
+// SelectCtx executes a query within the transaction using the provided context and scans all
+// resulting rows into dest. See Datasource.SelectCtx for details on inspection and events.
+func (t *Transaction) SelectCtx(ctx context.Context, dest any, query string, args ...any) wrapify.R {
+	tx, response, ok := t.session()
+	if !ok {
+		return response
+	}
+	return t.ds.selectContext(ctx, tx, "Tx.Select", dest, query, args)
+}
+
+// Get executes a query within the transaction and scans the first resulting row into dest.
+// It is equivalent to GetCtx with context.Background().
+func (t *Transaction) Get(dest any, query string, args ...any) wrapify.R {
+	return t.GetCtx(context.Background(), dest, query, args...)
+}
+
+// GetCtx executes a query within the transaction using the provided context and scans the first
+// resulting row into dest. See Datasource.GetCtx for details on inspection and events.
+func (t *Transaction) GetCtx(ctx context.Context, dest any, query string, args ...any) wrapify.R {
+	tx, response, ok := t.session()
+	if !ok {
+		return response
+	}
+	return t.ds.getContext(ctx, tx, "Tx.Get", dest, query, args)
+}
+
+// Queryx executes a query within the transaction and returns the resulting *sqlx.Rows.
+// It is equivalent to QueryxCtx with context.Background().
+func (t *Transaction) Queryx(query string, args ...any) (*sqlx.Rows, wrapify.R) {
+	return t.QueryxCtx(context.Background(), query, args...)
+}
+
+// QueryxCtx executes a query within the transaction using the provided context and returns the
+// resulting *sqlx.Rows. See Datasource.QueryxCtx for details on inspection and events.
+func (t *Transaction) QueryxCtx(ctx context.Context, query string, args ...any) (*sqlx.Rows, wrapify.R) {
+	tx, response, ok := t.session()
+	if !ok {
+		return nil, response
+	}
+	return t.ds.queryxContext(ctx, tx, "Tx.Queryx", query, args)
+}
+
+// NamedExec executes a query using named parameters within the transaction.
+// It is equivalent to NamedExecCtx with context.Background().
+func (t *Transaction) NamedExec(query string, arg any) (sql.Result, wrapify.R) {
+	return t.NamedExecCtx(context.Background(), query, arg)
+}
+
+// NamedExecCtx executes a query using named parameters within the transaction using the provided
+// context. See Datasource.NamedExecCtx for details on inspection and events.
+func (t *Transaction) NamedExecCtx(ctx context.Context, query string, arg any) (sql.Result, wrapify.R) {
+	tx, response, ok := t.session()
+	if !ok {
+		return nil, response
+	}
+	return t.ds.namedExecContext(ctx, tx, "Tx.NamedExec", query, arg)
+}
+
+// NamedQuery executes a query using named parameters within the transaction and returns the rows.
+// It is equivalent to NamedQueryCtx with context.Background().
+func (t *Transaction) NamedQuery(query string, arg any) (*sqlx.Rows, wrapify.R) {
+	return t.NamedQueryCtx(context.Background(), query, arg)
+}
+
+// NamedQueryCtx executes a query using named parameters within the transaction using the provided
+// context and returns the rows. See Datasource.NamedQueryCtx for details on inspection and events.
+func (t *Transaction) NamedQueryCtx(ctx context.Context, query string, arg any) (*sqlx.Rows, wrapify.R) {
+	tx, response, ok := t.session()
+	if !ok {
+		return nil, response
+	}
+	return t.ds.namedQueryContext(ctx, tx, "Tx.NamedQuery", query, arg)
+}
+
+// session returns the underlying *sqlx.Tx when the transaction is still active.
+// Otherwise, it returns the response explaining why statements cannot be executed.
+func (t *Transaction) session() (*sqlx.Tx, wrapify.R, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if !t.active || t.tx == nil {
+		return nil, wrapify.WrapBadRequest("Transaction is not active", nil).BindCause().Reply(), false
+	}
+	return t.tx, t.wrap, true
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Inspected Execution Core
+//_______________________________________________________________________
+
+// execContext executes a statement on the given sqlx executor (a *sqlx.DB or *sqlx.Tx),
+// inspects it under funcName, and dispatches an EventQueryExec event.
+//
+// Like every function of the execution core, it retries the statement on transient errors
+// according to the retry policy of the settings (see RetryPolicy); each attempt is inspected.
+func (d *Datasource) execContext(ctx context.Context, ext sqlx.ExtContext, funcName, query string, args []any) (sql.Result, wrapify.R) {
+	var result sql.Result
+	err := d.retry(ctx, ext, funcName, isReadQuery(query), func() (err error) {
+		// Start inspection
+		done := d.Inspect(funcName, query, args...)
+		result, err = ext.ExecContext(ctx, query, args...)
+		// End inspection
+		done()
+		return err
+	})
+
+	if err != nil {
+		response := wrapQueryErr(ctx, err, "An error occurred while executing the query", nil)
+		d.dispatchEvent(EventQueryExec, queryErrLevel(ctx, err), response.Reply())
+		return nil, response.Reply()
+	}
+
+	affected, _ := result.RowsAffected()
+	response := wrapify.WrapOk("Query executed successfully", nil).
+		WithDebuggingKV("rows_affected", affected).
+		WithTotal(int(affected)).
+		Reply()
+	d.dispatchEvent(EventQueryExec, EventLevelSuccess, response)
+	return result, response
+}
+
+// selectContext scans all rows of a query into dest using the given sqlx executor,
+// inspects it under funcName, and dispatches an EventQuerySelect event.
+func (d *Datasource) selectContext(ctx context.Context, ext sqlx.ExtContext, funcName string, dest any, query string, args []any) wrapify.R {
+	err := d.retry(ctx, ext, funcName, isReadQuery(query), func() error {
+		resetDest(dest)
+		// Start inspection
+		done := d.Inspect(funcName, query, args...)
+		err := sqlx.SelectContext(ctx, ext, dest, query, args...)
+		// End inspection
+		done()
+		return err
+	})
+
+	if err != nil {
+		response := wrapQueryErr(ctx, err, "An error occurred while selecting rows", nil)
+		d.dispatchEvent(EventQuerySelect, queryErrLevel(ctx, err), response.Reply())
+		return response.Reply()
+	}
+
+	total := sliceLen(dest)
+	response := wrapify.WrapOk("Selected rows successfully", dest).WithTotal(total).Reply()
+	d.dispatchEvent(EventQuerySelect, EventLevelSuccess, response)
+	return response
+}
+
+// getContext scans a single row of a query into dest using the given sqlx executor,
+// inspects it under funcName, and dispatches an EventQueryGet event.
+func (d *Datasource) getContext(ctx context.Context, ext sqlx.ExtContext, funcName string, dest any, query string, args []any) wrapify.R {
+	err := d.retry(ctx, ext, funcName, isReadQuery(query), func() error {
+		// Start inspection
+		done := d.Inspect(funcName, query, args...)
+		err := sqlx.GetContext(ctx, ext, dest, query, args...)
+		// End inspection
+		done()
+		return err
+	})
+
+	if errors.Is(err, sql.ErrNoRows) {
+		response := wrapify.WrapNotFound("No rows found for the query", nil).WithErrSck(err)
+		d.dispatchEvent(EventQueryGet, EventLevelError, response.Reply())
+		return response.Reply()
+	}
+	if err != nil {
+		response := wrapQueryErr(ctx, err, "An error occurred while retrieving the row", nil)
+		d.dispatchEvent(EventQueryGet, queryErrLevel(ctx, err), response.Reply())
+		return response.Reply()
+	}
+
+	response := wrapify.WrapOk("Retrieved row successfully", dest).WithTotal(1).Reply()
+	d.dispatchEvent(EventQueryGet, EventLevelSuccess, response)
+	return response
+}
+
+// queryxContext runs a query returning rows using the given sqlx executor,
+// inspects it under funcName, and dispatches an EventQueryRows event.
+func (d *Datasource) queryxContext(ctx context.Context, ext sqlx.ExtContext, funcName, query string, args []any) (*sqlx.Rows, wrapify.R) {
+	var rows *sqlx.Rows
+	err := d.retry(ctx, ext, funcName, isReadQuery(query), func() (err error) {
+		// Start inspection
+		done := d.Inspect(funcName, query, args...)
+		rows, err = ext.QueryxContext(ctx, query, args...)
+		// End inspection
+		done()
+		return err
+	})
+
+	if err != nil {
+		response := wrapQueryErr(ctx, err, "An error occurred while querying rows", nil)
+		d.dispatchEvent(EventQueryRows, queryErrLevel(ctx, err), response.Reply())
+		return nil, response.Reply()
+	}
+
+	response := wrapify.WrapOk("Query executed successfully", nil).Reply()
+	d.dispatchEvent(EventQueryRows, EventLevelSuccess, response)
+	return rows, response
+}
+
+// namedExecContext executes a statement with named parameters using the given sqlx executor,
+// inspects it under funcName, and dispatches an EventQueryNamedExec event.
+func (d *Datasource) namedExecContext(ctx context.Context, ext sqlx.ExtContext, funcName, query string, arg any) (sql.Result, wrapify.R) {
+	var result sql.Result
+	err := d.retry(ctx, ext, funcName, isReadQuery(query), func() (err error) {
+		// Start inspection
+		done := d.Inspect(funcName, query, arg)
+		result, err = sqlx.NamedExecContext(ctx, ext, query, arg)
+		// End inspection
+		done()
+		return err
+	})
+
+	if err != nil {
+		response := wrapQueryErr(ctx, err, "An error occurred while executing the named query", nil)
+		d.dispatchEvent(EventQueryNamedExec, queryErrLevel(ctx, err), response.Reply())
+		return nil, response.Reply()
+	}
+
+	affected, _ := result.RowsAffected()
+	response := wrapify.WrapOk("Named query executed successfully", nil).
+		WithDebuggingKV("rows_affected", affected).
+		WithTotal(int(affected)).
+		Reply()
+	d.dispatchEvent(EventQueryNamedExec, EventLevelSuccess, response)
+	return result, response
+}
+
+// namedQueryContext runs a query with named parameters returning rows using the given sqlx executor,
+// inspects it under funcName, and dispatches an EventQueryNamedQuery event.
+func (d *Datasource) namedQueryContext(ctx context.Context, ext sqlx.ExtContext, funcName, query string, arg any) (*sqlx.Rows, wrapify.R) {
+	var rows *sqlx.Rows
+	err := d.retry(ctx, ext, funcName, isReadQuery(query), func() (err error) {
+		// Start inspection
+		done := d.Inspect(funcName, query, arg)
+		rows, err = sqlx.NamedQueryContext(ctx, ext, query, arg)
+		// End inspection
+		done()
+		return err
+	})
+
+	if err != nil {
+		response := wrapQueryErr(ctx, err, "An error occurred while querying rows with named parameters", nil)
+		d.dispatchEvent(EventQueryNamedQuery, queryErrLevel(ctx, err), response.Reply())
+		return nil, response.Reply()
+	}
+
+	response := wrapify.WrapOk("Named query executed successfully", nil).Reply()
+	d.dispatchEvent(EventQueryNamedQuery, EventLevelSuccess, response)
+	return rows, response
+}
+
+// sliceLen returns the length of the slice pointed to by dest, or 0 if dest is not a pointer to a slice.
+func sliceLen(dest any) int {
+	rv := reflect.Indirect(reflect.ValueOf(dest))
+	if rv.Kind() != reflect.Slice {
+		return 0
+	}
+	return rv.Len()
+}
+
+// executor implements Executor: statements run on the connection pool of the Datasource.
+func (d *Datasource) executor() (*Datasource, sqlx.ExtContext, wrapify.R, bool) {
+	if !d.IsConnected() {
+		return d, nil, d.State(), false
+	}
+	return d, d.ext(), d.State(), true
+}
+
+// executor implements Executor: statements run within the transaction and are observed
+// by the Datasource that started it.
+func (t *Transaction) executor() (*Datasource, sqlx.ExtContext, wrapify.R, bool) {
+	tx, response, ok := t.session()
+	if !ok {
+		return t.ds, nil, response, false
+	}
+	return t.ds, tx, response, true
+}
@@ -1,6 +1,7 @@
 package pgc
 
 import (
+	"context"
 	"fmt"
 	"sort"
 	"strings"
@@ -28,6 +29,12 @@ import (
 // Returns:
 //   - A wrapify.R instance encapsulating either the successful retrieval of table names or the error encountered.
 func (d *Datasource) Tables() (tables []string, response wrapify.R) {
+	return d.TablesCtx(context.Background())
+}
+
+// TablesCtx is the context-aware variant of Tables: the catalog query is bound to ctx and is abandoned
+// as soon as ctx is cancelled (StatusClientClosedRequest) or its deadline expires (408).
+func (d *Datasource) TablesCtx(ctx context.Context) (tables []string, response wrapify.R) {
 	if !d.IsConnected() {
 		return tables, d.State()
 	}
@@ -36,13 +43,13 @@ func (d *Datasource) Tables() (tables []string, response wrapify.R) {
 
 	// Start inspection
 	done := d.Inspect("Tables", query)
-	err := d.Conn().Select(&tables, query)
+	err := d.reader("Tables").SelectContext(ctx, &tables, query)
 	// End inspection
 	done()
 
 	if err != nil {
-		response := wrapify.WrapInternalServerError("An error occurred while retrieving the list of tables", tables).WithErrSck(err)
-		d.dispatchEvent(EventTableListing, EventLevelError, response.Reply())
+		response := wrapQueryErr(ctx, err, "An error occurred while retrieving the list of tables", tables)
+		d.dispatchEvent(EventTableListing, queryErrLevel(ctx, err), response.Reply())
 		return tables, response.Reply()
 	}
 
@@ -76,6 +83,12 @@ func (d *Datasource) Tables() (tables []string, response wrapify.R) {
 //   - A wrapify.R instance that encapsulates either the list of function names or an error message,
 //     along with metadata such as the total count of functions.
 func (d *Datasource) Functions() (functions []string, response wrapify.R) {
+	return d.FunctionsCtx(context.Background())
+}
+
+// FunctionsCtx is the context-aware variant of Functions: the catalog query is bound to ctx and is abandoned
+// as soon as ctx is cancelled (StatusClientClosedRequest) or its deadline expires (408).
+func (d *Datasource) FunctionsCtx(ctx context.Context) (functions []string, response wrapify.R) {
 	if !d.IsConnected() {
 		return functions, d.State()
 	}
@@ -89,13 +102,13 @@ func (d *Datasource) Functions() (functions []string, response wrapify.R) {
 
 	// Start inspection
 	done := d.Inspect("Functions", query, d.conf.Database())
-	err := d.Conn().Select(&functions, query, d.conf.Database())
+	err := d.reader("Functions").SelectContext(ctx, &functions, query, d.conf.Database())
 	// End inspection
 	done()
 
 	if err != nil {
-		response := wrapify.WrapInternalServerError("An error occurred while retrieving the list of functions", functions).WithErrSck(err)
-		d.dispatchEvent(EventFunctionListing, EventLevelError, response.Reply())
+		response := wrapQueryErr(ctx, err, "An error occurred while retrieving the list of functions", functions)
+		d.dispatchEvent(EventFunctionListing, queryErrLevel(ctx, err), response.Reply())
 		return functions, response.Reply()
 	}
 
@@ -127,6 +140,12 @@ func (d *Datasource) Functions() (functions []string, response wrapify.R) {
 //   - A wrapify.R instance that encapsulates either the list of procedure names or an error message, along with metadata
 //     such as the total count of procedures.
 func (d *Datasource) Procedures() (procedures []string, response wrapify.R) {
+	return d.ProceduresCtx(context.Background())
+}
+
+// ProceduresCtx is the context-aware variant of Procedures: the catalog query is bound to ctx and is abandoned
+// as soon as ctx is cancelled (StatusClientClosedRequest) or its deadline expires (408).
+func (d *Datasource) ProceduresCtx(ctx context.Context) (procedures []string, response wrapify.R) {
 	if !d.IsConnected() {
 		return procedures, d.State()
 	}
@@ -140,13 +159,13 @@ func (d *Datasource) Procedures() (procedures []string, response wrapify.R) {
 
 	// Start inspection
 	done := d.Inspect("Procedures", query, d.conf.Database())
-	err := d.Conn().Select(&procedures, query, d.conf.Database())
+	err := d.reader("Procedures").SelectContext(ctx, &procedures, query, d.conf.Database())
 	// End inspection
 	done()
 
 	if err != nil {
-		response := wrapify.WrapInternalServerError("An error occurred while retrieving the list of procedures", procedures).WithErrSck(err)
-		d.dispatchEvent(EventProcedureListing, EventLevelError, response.Reply())
+		response := wrapQueryErr(ctx, err, "An error occurred while retrieving the list of procedures", procedures)
+		d.dispatchEvent(EventProcedureListing, queryErrLevel(ctx, err), response.Reply())
 		return procedures, response.Reply()
 	}
 
@@ -190,6 +209,12 @@ func (d *Datasource) Procedures() (procedures []string, response wrapify.R) {
 //   - A wrapify.R instance that encapsulates either the retrieved function metadata or an error message,
 //     along with additional metadata such as the total count of metadata segments.
 func (d *Datasource) FuncSpec(function string) (fsm []FuncsSpec, response wrapify.R) {
+	return d.FuncSpecCtx(context.Background(), function)
+}
+
+// FuncSpecCtx is the context-aware variant of FuncSpec: the catalog query is bound to ctx and is abandoned
+// as soon as ctx is cancelled (StatusClientClosedRequest) or its deadline expires (408).
+func (d *Datasource) FuncSpecCtx(ctx context.Context, function string) (fsm []FuncsSpec, response wrapify.R) {
 	if !d.IsConnected() {
 		return fsm, d.State()
 	}
@@ -215,13 +240,13 @@ func (d *Datasource) FuncSpec(function string) (fsm []FuncsSpec, response wrapif
 
 	// Start inspection
 	done := d.Inspect("FuncSpec", query, d.conf.Database(), function)
-	err := d.Conn().Select(&fsm, query, d.conf.Database(), function)
+	err := d.reader("FuncSpec").SelectContext(ctx, &fsm, query, d.conf.Database(), function)
 	// End inspection
 	done()
 
 	if err != nil {
-		response := wrapify.WrapInternalServerError(fmt.Sprintf("An error occurred while retrieving the function '%s' metadata", function), fsm).WithErrSck(err)
-		d.dispatchEvent(EventFunctionMetadata, EventLevelError, response.Reply())
+		response := wrapQueryErr(ctx, err, fmt.Sprintf("An error occurred while retrieving the function '%s' metadata", function), fsm)
+		d.dispatchEvent(EventFunctionMetadata, queryErrLevel(ctx, err), response.Reply())
 		return fsm, response.Reply()
 	}
 
@@ -259,6 +284,12 @@ func (d *Datasource) FuncSpec(function string) (fsm []FuncsSpec, response wrapif
 //   - A wrapify.R instance that encapsulates either the function's complete definition or an error message,
 //     along with additional metadata.
 func (d *Datasource) FuncDef(function string) (def string, response wrapify.R) {
+	return d.FuncDefCtx(context.Background(), function)
+}
+
+// FuncDefCtx is the context-aware variant of FuncDef: the catalog query is bound to ctx and is abandoned
+// as soon as ctx is cancelled (StatusClientClosedRequest) or its deadline expires (408).
+func (d *Datasource) FuncDefCtx(ctx context.Context, function string) (def string, response wrapify.R) {
 	if !d.IsConnected() {
 		return def, d.State()
 	}
@@ -272,13 +303,13 @@ func (d *Datasource) FuncDef(function string) (def string, response wrapify.R) {
 
 	// Start inspection
 	done := d.Inspect("FuncDef", query, function)
-	err := d.Conn().QueryRow(query, function).Scan(&def)
+	err := d.reader("FuncDef").QueryRowContext(ctx, query, function).Scan(&def)
 	// End inspection
 	done()
 
 	if err != nil {
-		response := wrapify.WrapInternalServerError(fmt.Sprintf("An error occurred while retrieving the function '%s' metadata", function), def).WithErrSck(err)
-		d.dispatchEvent(EventFunctionDefinition, EventLevelError, response.Reply())
+		response := wrapQueryErr(ctx, err, fmt.Sprintf("An error occurred while retrieving the function '%s' metadata", function), def)
+		d.dispatchEvent(EventFunctionDefinition, queryErrLevel(ctx, err), response.Reply())
 		return def, response.Reply()
 	}
 
@@ -319,6 +350,12 @@ func (d *Datasource) FuncDef(function string) (def string, response wrapify.R) {
 //   - A wrapify.R instance that encapsulates either the procedure's complete definition or an error message,
 //     along with additional metadata such as the total count (1 in this case).
 func (d *Datasource) ProcDef(procedure string) (def string, response wrapify.R) {
+	return d.ProcDefCtx(context.Background(), procedure)
+}
+
+// ProcDefCtx is the context-aware variant of ProcDef: the catalog query is bound to ctx and is abandoned
+// as soon as ctx is cancelled (StatusClientClosedRequest) or its deadline expires (408).
+func (d *Datasource) ProcDefCtx(ctx context.Context, procedure string) (def string, response wrapify.R) {
 	if !d.IsConnected() {
 		return def, d.State()
 	}
@@ -339,13 +376,13 @@ func (d *Datasource) ProcDef(procedure string) (def string, response wrapify.R)
 
 	// Start inspection
 	done := d.Inspect("ProcDef", query, procedure)
-	err := d.Conn().QueryRow(query, procedure).Scan(&def)
+	err := d.reader("ProcDef").QueryRowContext(ctx, query, procedure).Scan(&def)
 	// End inspection
 	done()
 
 	if err != nil {
-		response := wrapify.WrapInternalServerError(fmt.Sprintf("An error occurred while retrieving the procedure '%s' metadata", procedure), def).WithErrSck(err)
-		d.dispatchEvent(EventProcedureDefinition, EventLevelError, response.Reply())
+		response := wrapQueryErr(ctx, err, fmt.Sprintf("An error occurred while retrieving the procedure '%s' metadata", procedure), def)
+		d.dispatchEvent(EventProcedureDefinition, queryErrLevel(ctx, err), response.Reply())
 		return def, response.Reply()
 	}
 
@@ -382,6 +419,12 @@ func (d *Datasource) ProcDef(procedure string) (def string, response wrapify.R)
 //   - A wrapify.R instance that encapsulates either the generated DDL statement (on success) or an error message
 //     (on failure), along with additional metadata.
 func (d *Datasource) TableDef(table string) (ddl string, response wrapify.R) {
+	return d.TableDefCtx(context.Background(), table)
+}
+
+// TableDefCtx is the context-aware variant of TableDef: the catalog query is bound to ctx and is abandoned
+// as soon as ctx is cancelled (StatusClientClosedRequest) or its deadline expires (408).
+func (d *Datasource) TableDefCtx(ctx context.Context, table string) (ddl string, response wrapify.R) {
 	if !d.IsConnected() {
 		return ddl, d.State()
 	}
@@ -411,14 +454,13 @@ func (d *Datasource) TableDef(table string) (ddl string, response wrapify.R) {
 
 	// Start inspection
 	done := d.Inspect("TableDef", query, table)
-	err := d.Conn().QueryRow(query, table).Scan(&ddl)
+	err := d.reader("TableDef").QueryRowContext(ctx, query, table).Scan(&ddl)
 	// End inspection
 	done()
 
 	if err != nil {
-		response := wrapify.WrapInternalServerError(fmt.Sprintf("An error occurred while generating the table definition for table '%s'", table), ddl).
-			WithErrSck(err)
-		d.dispatchEvent(EventTableDefinition, EventLevelError, response.Reply())
+		response := wrapQueryErr(ctx, err, fmt.Sprintf("An error occurred while generating the table definition for table '%s'", table), ddl)
+		d.dispatchEvent(EventTableDefinition, queryErrLevel(ctx, err), response.Reply())
 		return ddl, response.Reply()
 	}
 
@@ -460,6 +502,12 @@ func (d *Datasource) TableDef(table string) (ddl string, response wrapify.R) {
 //   - A wrapify.R instance that encapsulates the complete DDL script for the table (on success) or an error message
 //     (on failure), along with additional metadata.
 func (d *Datasource) TableDefPlus(table string) (ddl string, response wrapify.R) {
+	return d.TableDefPlusCtx(context.Background(), table)
+}
+
+// TableDefPlusCtx is the context-aware variant of TableDefPlus: the catalog query is bound to ctx and is abandoned
+// as soon as ctx is cancelled (StatusClientClosedRequest) or its deadline expires (408).
+func (d *Datasource) TableDefPlusCtx(ctx context.Context, table string) (ddl string, response wrapify.R) {
 	if !d.IsConnected() {
 		return ddl, d.State()
 	}
@@ -524,14 +572,13 @@ func (d *Datasource) TableDefPlus(table string) (ddl string, response wrapify.R)
 	`
 	// Start inspection
 	done := d.Inspect("TableDefPlus-ddl", ddlQuery, table)
-	err := d.Conn().QueryRow(ddlQuery, table).Scan(&tableDDL)
+	err := d.reader("TableDefPlus-ddl").QueryRowContext(ctx, ddlQuery, table).Scan(&tableDDL)
 	// End inspection
 	done()
 
 	if err != nil {
-		response := wrapify.WrapInternalServerError(fmt.Sprintf("An error occurred while generating the table definition for table '%s'", table), tableDDL).
-			WithErrSck(err)
-		d.dispatchEvent(EventTableDefinition, EventLevelError, response.Reply())
+		response := wrapQueryErr(ctx, err, fmt.Sprintf("An error occurred while generating the table definition for table '%s'", table), tableDDL)
+		d.dispatchEvent(EventTableDefinition, queryErrLevel(ctx, err), response.Reply())
 		return ddl, response.Reply()
 	}
 
@@ -562,10 +609,15 @@ func (d *Datasource) TableDefPlus(table string) (ddl string, response wrapify.R)
 	`
 	// Start inspection
 	done = d.Inspect("TableDefPlus-fk", fkQuery, table)
-	err = d.Conn().QueryRow(fkQuery, table).Scan(&fkDDL)
+	err = d.reader("TableDefPlus-fk").QueryRowContext(ctx, fkQuery, table).Scan(&fkDDL)
 	// End inspection
 	done()
 
+	if ctxErr(ctx, err) != nil {
+		response := wrapQueryErr(ctx, err, fmt.Sprintf("An error occurred while retrieving foreign key constraints for table '%s'", table), nil)
+		d.dispatchEvent(EventTableDefinition, queryErrLevel(ctx, err), response.Reply())
+		return ddl, response.Reply()
+	}
 	if err != nil {
 		// If an error occurs (e.g., no foreign key constraints exist), default to an empty string.
 		fkDDL = ""
@@ -583,10 +635,15 @@ func (d *Datasource) TableDefPlus(table string) (ddl string, response wrapify.R)
 
 	// Start inspection
 	done = d.Inspect("TableDefPlus-indexes", indexQuery, table)
-	err = d.Conn().QueryRow(indexQuery, table).Scan(&indexes)
+	err = d.reader("TableDefPlus-indexes").QueryRowContext(ctx, indexQuery, table).Scan(&indexes)
 	// End inspection
 	done()
 
+	if ctxErr(ctx, err) != nil {
+		response := wrapQueryErr(ctx, err, fmt.Sprintf("An error occurred while retrieving indexes for table '%s'", table), nil)
+		d.dispatchEvent(EventTableDefinition, queryErrLevel(ctx, err), response.Reply())
+		return ddl, response.Reply()
+	}
 	if err != nil {
 		// If an error occurs (e.g., no indexes exist), default to an empty string.
 		indexes = ""
@@ -635,6 +692,12 @@ func (d *Datasource) TableDefPlus(table string) (ddl string, response wrapify.R)
 // Returns:
 //   - A wrapify.R instance encapsulating either the retrieved metadata (on success) or an error message (on failure).
 func (d *Datasource) TableKeys(table string) (keys []TableKeysDef, response wrapify.R) {
+	return d.TableKeysCtx(context.Background(), table)
+}
+
+// TableKeysCtx is the context-aware variant of TableKeys: the catalog query is bound to ctx and is abandoned
+// as soon as ctx is cancelled (StatusClientClosedRequest) or its deadline expires (408).
+func (d *Datasource) TableKeysCtx(ctx context.Context, table string) (keys []TableKeysDef, response wrapify.R) {
 	if !d.IsConnected() {
 		return keys, d.State()
 	}
@@ -663,12 +726,13 @@ func (d *Datasource) TableKeys(table string) (keys []TableKeysDef, response wrap
 	`
 	// Start inspection
 	done := d.Inspect("TableKeys", query, table)
-	rows, err := d.Conn().Query(query, table)
+	rows, err := d.reader("TableKeys").QueryContext(ctx, query, table)
 	// End inspection
 	done()
 
 	if err != nil {
-		response := wrapify.WrapInternalServerError(fmt.Sprintf("An error occurred while retrieving the table '%s' metadata", table), nil).WithErrSck(err)
+		response := wrapQueryErr(ctx, err, fmt.Sprintf("An error occurred while retrieving the table '%s' metadata", table), nil)
+		d.dispatchEvent(EventTableKeysIndexes, queryErrLevel(ctx, err), response.Reply())
 		return keys, response.Reply()
 	}
 	defer rows.Close()
@@ -676,16 +740,16 @@ func (d *Datasource) TableKeys(table string) (keys []TableKeysDef, response wrap
 	for rows.Next() {
 		var m TableKeysDef
 		if err := rows.Scan(&m.Name, &m.Type, &m.Desc); err != nil {
-			response := wrapify.WrapInternalServerError(fmt.Sprintf("An error occurred while scanning rows the table '%s' metadata", table), nil).WithErrSck(err)
-			d.dispatchEvent(EventTableKeysIndexes, EventLevelError, response.Reply())
+			response := wrapQueryErr(ctx, err, fmt.Sprintf("An error occurred while scanning rows the table '%s' metadata", table), nil)
+			d.dispatchEvent(EventTableKeysIndexes, queryErrLevel(ctx, err), response.Reply())
 			return keys, response.Reply()
 		}
 		keys = append(keys, m)
 	}
 
 	if err := rows.Err(); err != nil {
-		response := wrapify.WrapInternalServerError(fmt.Sprintf("An error occurred while retrieving rows the table '%s' metadata", table), nil).WithErrSck(err)
-		d.dispatchEvent(EventTableKeysIndexes, EventLevelError, response.Reply())
+		response := wrapQueryErr(ctx, err, fmt.Sprintf("An error occurred while retrieving rows the table '%s' metadata", table), nil)
+		d.dispatchEvent(EventTableKeysIndexes, queryErrLevel(ctx, err), response.Reply())
 		return keys, response.Reply()
 	}
 
@@ -695,8 +759,9 @@ func (d *Datasource) TableKeys(table string) (keys []TableKeysDef, response wrap
 		return keys, response.Reply()
 	}
 
+	response = wrapify.WrapOk(fmt.Sprintf("Retrieved table '%s' keys and indexes metadata successfully", table), keys).WithTotal(len(keys)).Reply()
 	d.dispatchEvent(EventTableKeysIndexes, EventLevelSuccess, response.Reply())
-	return keys, wrapify.WrapOk(fmt.Sprintf("Retrieved table '%s' keys and indexes metadata successfully", table), keys).WithTotal(len(keys)).Reply()
+	return keys, response
 }
 
 // ColsSpec retrieves metadata for all columns of the specified table from the PostgreSQL database.
@@ -719,6 +784,12 @@ func (d *Datasource) TableKeys(table string) (keys []TableKeysDef, response wrap
 //   - A wrapify.R instance that encapsulates either the retrieved column metadata or an error message,
 //     along with additional metadata (e.g., the total count of columns).
 func (d *Datasource) ColsSpec(table string) (cols []ColsSpec, response wrapify.R) {
+	return d.ColsSpecCtx(context.Background(), table)
+}
+
+// ColsSpecCtx is the context-aware variant of ColsSpec: the catalog query is bound to ctx and is abandoned
+// as soon as ctx is cancelled (StatusClientClosedRequest) or its deadline expires (408).
+func (d *Datasource) ColsSpecCtx(ctx context.Context, table string) (cols []ColsSpec, response wrapify.R) {
 	if !d.IsConnected() {
 		return cols, d.State()
 	}
@@ -742,13 +813,13 @@ func (d *Datasource) ColsSpec(table string) (cols []ColsSpec, response wrapify.R
 
 	// Start inspection
 	done := d.Inspect("ColsSpec", query, table)
-	rows, err := d.Conn().Query(query, table)
+	rows, err := d.reader("ColsSpec").QueryContext(ctx, query, table)
 	// End inspection
 	done()
 
 	if err != nil {
-		response := wrapify.WrapInternalServerError(fmt.Sprintf("An error occurred while retrieving the columns metadata by table '%s'", table), nil).WithErrSck(err)
-		d.dispatchEvent(EventTableColsSpec, EventLevelError, response.Reply())
+		response := wrapQueryErr(ctx, err, fmt.Sprintf("An error occurred while retrieving the columns metadata by table '%s'", table), nil)
+		d.dispatchEvent(EventTableColsSpec, queryErrLevel(ctx, err), response.Reply())
 		return cols, response.Reply()
 	}
 	defer rows.Close()
@@ -756,16 +827,16 @@ func (d *Datasource) ColsSpec(table string) (cols []ColsSpec, response wrapify.R
 	for rows.Next() {
 		var m ColsSpec
 		if err := rows.Scan(&m.Column, &m.Type, &m.MaxLength); err != nil {
-			response := wrapify.WrapInternalServerError(fmt.Sprintf("An error occurred while scanning the columns metadata by table '%s' ", table), nil).WithErrSck(err)
-			d.dispatchEvent(EventTableColsSpec, EventLevelError, response.Reply())
+			response := wrapQueryErr(ctx, err, fmt.Sprintf("An error occurred while scanning the columns metadata by table '%s' ", table), nil)
+			d.dispatchEvent(EventTableColsSpec, queryErrLevel(ctx, err), response.Reply())
 			return cols, response.Reply()
 		}
 		cols = append(cols, m)
 	}
 
 	if err := rows.Err(); err != nil {
-		response := wrapify.WrapInternalServerError(fmt.Sprintf("An error occurred while retrieving rows and mapping the columns' metadata for the table '%s'", table), nil).WithErrSck(err)
-		d.dispatchEvent(EventTableColsSpec, EventLevelError, response.Reply())
+		response := wrapQueryErr(ctx, err, fmt.Sprintf("An error occurred while retrieving rows and mapping the columns' metadata for the table '%s'", table), nil)
+		d.dispatchEvent(EventTableColsSpec, queryErrLevel(ctx, err), response.Reply())
 		return cols, response.Reply()
 	}
 
@@ -794,6 +865,12 @@ func (d *Datasource) ColsSpec(table string) (cols []ColsSpec, response wrapify.R
 //   - A wrapify.R instance that encapsulates either a slice of TableWithColumns containing
 //     all tables with all specified columns, or an error message, along with additional metadata.
 func (d *Datasource) TablesByCols(columns []string) (stats []TableColsSpec, response wrapify.R) {
+	return d.TablesByColsCtx(context.Background(), columns)
+}
+
+// TablesByColsCtx is the context-aware variant of TablesByCols: the catalog query is bound to ctx and is abandoned
+// as soon as ctx is cancelled (StatusClientClosedRequest) or its deadline expires (408).
+func (d *Datasource) TablesByColsCtx(ctx context.Context, columns []string) (stats []TableColsSpec, response wrapify.R) {
 	if !d.IsConnected() {
 		return stats, d.State()
 	}
@@ -819,16 +896,16 @@ func (d *Datasource) TablesByCols(columns []string) (stats []TableColsSpec, resp
 
 	// Start inspection
 	done := d.Inspect("TablesByCols", query, pq.Array(columns), len(columns))
-	rows, err := d.Conn().Query(query, pq.Array(columns), len(columns))
+	rows, err := d.reader("TablesByCols").QueryContext(ctx, query, pq.Array(columns), len(columns))
 	// End inspection
 	done()
 
 	if err != nil {
-		response := wrapify.WrapInternalServerError(
+		response := wrapQueryErr(ctx, err,
 			fmt.Sprintf("An error occurred while searching for tables with columns %v", columns),
 			nil,
-		).WithErrSck(err)
-		d.dispatchEvent(EventTableSearchByCols, EventLevelError, response.Reply())
+		)
+		d.dispatchEvent(EventTableSearchByCols, queryErrLevel(ctx, err), response.Reply())
 		return stats, response.Reply()
 	}
 	defer rows.Close()
@@ -837,11 +914,11 @@ func (d *Datasource) TablesByCols(columns []string) (stats []TableColsSpec, resp
 		var r TableColsSpec
 		var matchedCols pq.StringArray
 		if err := rows.Scan(&r.SchemaName, &r.TableName, &matchedCols); err != nil {
-			response := wrapify.WrapInternalServerError(
+			response := wrapQueryErr(ctx, err,
 				fmt.Sprintf("An error occurred while scanning results for columns %v", columns),
 				nil,
-			).WithErrSck(err)
-			d.dispatchEvent(EventTableSearchByCols, EventLevelError, response.Reply())
+			)
+			d.dispatchEvent(EventTableSearchByCols, queryErrLevel(ctx, err), response.Reply())
 			return stats, response.Reply()
 		}
 		r.MatchedColumns = []string(matchedCols)
@@ -851,11 +928,11 @@ func (d *Datasource) TablesByCols(columns []string) (stats []TableColsSpec, resp
 	}
 
 	if err := rows.Err(); err != nil {
-		response := wrapify.WrapInternalServerError(
+		response := wrapQueryErr(ctx, err,
 			fmt.Sprintf("An error occurred while iterating results for columns %v", columns),
 			nil,
-		).WithErrSck(err)
-		d.dispatchEvent(EventTableSearchByCols, EventLevelError, response.Reply())
+		)
+		d.dispatchEvent(EventTableSearchByCols, queryErrLevel(ctx, err), response.Reply())
 		return stats, response.Reply()
 	}
 
@@ -890,6 +967,12 @@ func (d *Datasource) TablesByCols(columns []string) (stats []TableColsSpec, resp
 //   - A wrapify. R instance that encapsulates either a slice of TableWithColumns containing
 //     all tables with at least one specified column, or an error message.
 func (d *Datasource) TablesByAnyCols(columns []string) (stats []TableColsSpec, response wrapify.R) {
+	return d.TablesByAnyColsCtx(context.Background(), columns)
+}
+
+// TablesByAnyColsCtx is the context-aware variant of TablesByAnyCols: the catalog query is bound to ctx and is abandoned
+// as soon as ctx is cancelled (StatusClientClosedRequest) or its deadline expires (408).
+func (d *Datasource) TablesByAnyColsCtx(ctx context.Context, columns []string) (stats []TableColsSpec, response wrapify.R) {
 	if !d.IsConnected() {
 		return stats, d.State()
 	}
@@ -913,16 +996,16 @@ func (d *Datasource) TablesByAnyCols(columns []string) (stats []TableColsSpec, r
 
 	// Start inspection
 	done := d.Inspect("TablesByColsIn", query, pq.Array(columns))
-	rows, err := d.Conn().Query(query, pq.Array(columns))
+	rows, err := d.reader("TablesByColsIn").QueryContext(ctx, query, pq.Array(columns))
 	// End inspection
 	done()
 
 	if err != nil {
-		response := wrapify.WrapInternalServerError(
+		response := wrapQueryErr(ctx, err,
 			fmt.Sprintf("An error occurred while searching for tables with any columns %v", columns),
 			nil,
-		).WithErrSck(err)
-		d.dispatchEvent(EventTableSearchByAnyCols, EventLevelError, response.Reply())
+		)
+		d.dispatchEvent(EventTableSearchByAnyCols, queryErrLevel(ctx, err), response.Reply())
 		return stats, response.Reply()
 	}
 	defer rows.Close()
@@ -931,11 +1014,11 @@ func (d *Datasource) TablesByAnyCols(columns []string) (stats []TableColsSpec, r
 		var r TableColsSpec
 		var matchedCols pq.StringArray
 		if err := rows.Scan(&r.SchemaName, &r.TableName, &matchedCols); err != nil {
-			response := wrapify.WrapInternalServerError(
+			response := wrapQueryErr(ctx, err,
 				fmt.Sprintf("An error occurred while scanning results for columns %v", columns),
 				nil,
-			).WithErrSck(err)
-			d.dispatchEvent(EventTableSearchByAnyCols, EventLevelError, response.Reply())
+			)
+			d.dispatchEvent(EventTableSearchByAnyCols, queryErrLevel(ctx, err), response.Reply())
 			return stats, response.Reply()
 		}
 		r.MatchedColumns = []string(matchedCols)
@@ -945,11 +1028,11 @@ func (d *Datasource) TablesByAnyCols(columns []string) (stats []TableColsSpec, r
 	}
 
 	if err := rows.Err(); err != nil {
-		response := wrapify.WrapInternalServerError(
+		response := wrapQueryErr(ctx, err,
 			fmt.Sprintf("An error occurred while iterating results for columns %v", columns),
 			nil,
-		).WithErrSck(err)
-		d.dispatchEvent(EventTableSearchByAnyCols, EventLevelError, response.Reply())
+		)
+		d.dispatchEvent(EventTableSearchByAnyCols, queryErrLevel(ctx, err), response.Reply())
 		return stats, response.Reply()
 	}
 
@@ -979,6 +1062,12 @@ func (d *Datasource) TablesByAnyCols(columns []string) (stats []TableColsSpec, r
 // Returns:
 //   - A wrapify. R instance that encapsulates either a slice of TableWithColumns or an error message.
 func (d *Datasource) TablesByColsIn(schema string, columns []string) (stats []TableColsSpec, response wrapify.R) {
+	return d.TablesByColsInCtx(context.Background(), schema, columns)
+}
+
+// TablesByColsInCtx is the context-aware variant of TablesByColsIn: the catalog query is bound to ctx and is abandoned
+// as soon as ctx is cancelled (StatusClientClosedRequest) or its deadline expires (408).
+func (d *Datasource) TablesByColsInCtx(ctx context.Context, schema string, columns []string) (stats []TableColsSpec, response wrapify.R) {
 	if !d.IsConnected() {
 		return stats, d.State()
 	}
@@ -1003,16 +1092,16 @@ func (d *Datasource) TablesByColsIn(schema string, columns []string) (stats []Ta
 
 	// Start inspection
 	done := d.Inspect("TablesByColsIn", query, schema, pq.Array(columns), len(columns))
-	rows, err := d.Conn().Query(query, schema, pq.Array(columns), len(columns))
+	rows, err := d.reader("TablesByColsIn").QueryContext(ctx, query, schema, pq.Array(columns), len(columns))
 	// End inspection
 	done()
 
 	if err != nil {
-		response := wrapify.WrapInternalServerError(
+		response := wrapQueryErr(ctx, err,
 			fmt.Sprintf("An error occurred while searching for tables with columns %v in schema '%s'", columns, schema),
 			nil,
-		).WithErrSck(err)
-		d.dispatchEvent(EventTablesByColsIn, EventLevelError, response.Reply())
+		)
+		d.dispatchEvent(EventTablesByColsIn, queryErrLevel(ctx, err), response.Reply())
 		return stats, response.Reply()
 	}
 	defer rows.Close()
@@ -1021,11 +1110,11 @@ func (d *Datasource) TablesByColsIn(schema string, columns []string) (stats []Ta
 		var r TableColsSpec
 		var matchedCols pq.StringArray
 		if err := rows.Scan(&r.SchemaName, &r.TableName, &matchedCols); err != nil {
-			response := wrapify.WrapInternalServerError(
+			response := wrapQueryErr(ctx, err,
 				fmt.Sprintf("An error occurred while scanning results for columns %v in schema '%s'", columns, schema),
 				nil,
-			).WithErrSck(err)
-			d.dispatchEvent(EventTablesByColsIn, EventLevelError, response.Reply())
+			)
+			d.dispatchEvent(EventTablesByColsIn, queryErrLevel(ctx, err), response.Reply())
 			return stats, response.Reply()
 		}
 		r.MatchedColumns = []string(matchedCols)
@@ -1035,11 +1124,11 @@ func (d *Datasource) TablesByColsIn(schema string, columns []string) (stats []Ta
 	}
 
 	if err := rows.Err(); err != nil {
-		response := wrapify.WrapInternalServerError(
+		response := wrapQueryErr(ctx, err,
 			fmt.Sprintf("An error occurred while iterating results for columns %v in schema '%s'", columns, schema),
 			nil,
-		).WithErrSck(err)
-		d.dispatchEvent(EventTablesByColsIn, EventLevelError, response.Reply())
+		)
+		d.dispatchEvent(EventTablesByColsIn, queryErrLevel(ctx, err), response.Reply())
 		return stats, response.Reply()
 	}
 
@@ -1071,6 +1160,12 @@ func (d *Datasource) TablesByColsIn(schema string, columns []string) (stats []Ta
 // Returns:
 //   - A wrapify. R instance containing detailed matching information.
 func (d *Datasource) TablesByColsPlus(columns []string) (stats []TableColsSpecMeta, response wrapify.R) {
+	return d.TablesByColsPlusCtx(context.Background(), columns)
+}
+
+// TablesByColsPlusCtx is the context-aware variant of TablesByColsPlus: the catalog query is bound to ctx and is abandoned
+// as soon as ctx is cancelled (StatusClientClosedRequest) or its deadline expires (408).
+func (d *Datasource) TablesByColsPlusCtx(ctx context.Context, columns []string) (stats []TableColsSpecMeta, response wrapify.R) {
 	if !d.IsConnected() {
 		return stats, d.State()
 	}
@@ -1096,16 +1191,16 @@ func (d *Datasource) TablesByColsPlus(columns []string) (stats []TableColsSpecMe
 
 	// Start inspection
 	done := d.Inspect("TablesByColsPlus", query, pq.Array(columns))
-	rows, err := d.Conn().Query(query, pq.Array(columns))
+	rows, err := d.reader("TablesByColsPlus").QueryContext(ctx, query, pq.Array(columns))
 	// End inspection
 	done()
 
 	if err != nil {
-		response := wrapify.WrapInternalServerError(
+		response := wrapQueryErr(ctx, err,
 			fmt.Sprintf("An error occurred while searching for tables with columns %v", columns),
 			nil,
-		).WithErrSck(err)
-		d.dispatchEvent(EventTableSearchByCols, EventLevelError, response.Reply())
+		)
+		d.dispatchEvent(EventTableSearchByCols, queryErrLevel(ctx, err), response.Reply())
 		return stats, response.Reply()
 	}
 	defer rows.Close()
@@ -1115,11 +1210,11 @@ func (d *Datasource) TablesByColsPlus(columns []string) (stats []TableColsSpecMe
 	for rows.Next() {
 		var col ColsDef
 		if err := rows.Scan(&col.SchemaName, &col.TableName, &col.ColumnName, &col.DataType, &col.IsNullable); err != nil {
-			response := wrapify.WrapInternalServerError(
+			response := wrapQueryErr(ctx, err,
 				fmt.Sprintf("An error occurred while scanning results for columns %v", columns),
 				nil,
-			).WithErrSck(err)
-			d.dispatchEvent(EventTableSearchByCols, EventLevelError, response.Reply())
+			)
+			d.dispatchEvent(EventTableSearchByCols, queryErrLevel(ctx, err), response.Reply())
 			return stats, response.Reply()
 		}
 
@@ -1136,11 +1231,11 @@ func (d *Datasource) TablesByColsPlus(columns []string) (stats []TableColsSpecMe
 	}
 
 	if err := rows.Err(); err != nil {
-		response := wrapify.WrapInternalServerError(
+		response := wrapQueryErr(ctx, err,
 			fmt.Sprintf("An error occurred while iterating results for columns %v", columns),
 			nil,
-		).WithErrSck(err)
-		d.dispatchEvent(EventTableSearchByCols, EventLevelError, response.Reply())
+		)
+		d.dispatchEvent(EventTableSearchByCols, queryErrLevel(ctx, err), response.Reply())
 		return stats, response.Reply()
 	}
 
@@ -1204,6 +1299,12 @@ func (d *Datasource) TablesByColsPlus(columns []string) (stats []TableColsSpecMe
 //   - A TablePrivilegesSpecMeta containing the list of privileges and statistics.
 //   - A wrapify. R instance that encapsulates either the result or an error message.
 func (d *Datasource) TablePrivs(tables []string, privileges []string) (privs_spec TablePrivsSpecMeta, response wrapify.R) {
+	return d.TablePrivsCtx(context.Background(), tables, privileges)
+}
+
+// TablePrivsCtx is the context-aware variant of TablePrivs: the catalog query is bound to ctx and is abandoned
+// as soon as ctx is cancelled (StatusClientClosedRequest) or its deadline expires (408).
+func (d *Datasource) TablePrivsCtx(ctx context.Context, tables []string, privileges []string) (privs_spec TablePrivsSpecMeta, response wrapify.R) {
 	if !d.IsConnected() {
 		return privs_spec, d.State()
 	}
@@ -1236,16 +1337,16 @@ func (d *Datasource) TablePrivs(tables []string, privileges []string) (privs_spe
 
 	// Start inspection
 	done := d.Inspect("TablePrivs", query, pq.Array(tables), pq.Array(normalizedPrivileges))
-	rows, err := d.Conn().Query(query, pq.Array(tables), pq.Array(normalizedPrivileges))
+	rows, err := d.reader("TablePrivs").QueryContext(ctx, query, pq.Array(tables), pq.Array(normalizedPrivileges))
 	// End inspection
 	done()
 
 	if err != nil {
-		response := wrapify.WrapInternalServerError(
+		response := wrapQueryErr(ctx, err,
 			fmt.Sprintf("An error occurred while retrieving privileges for tables %v", tables),
 			nil,
-		).WithErrSck(err)
-		d.dispatchEvent(EventTablePrivileges, EventLevelError, response.Reply())
+		)
+		d.dispatchEvent(EventTablePrivileges, queryErrLevel(ctx, err), response.Reply())
 		return privs_spec, response.Reply()
 	}
 	defer rows.Close()
@@ -1256,11 +1357,11 @@ func (d *Datasource) TablePrivs(tables []string, privileges []string) (privs_spe
 	for rows.Next() {
 		var priv PrivsDef
 		if err := rows.Scan(&priv.Grantee, &priv.PrivilegeType, &priv.TableName); err != nil {
-			response := wrapify.WrapInternalServerError(
+			response := wrapQueryErr(ctx, err,
 				fmt.Sprintf("An error occurred while scanning privilege results for tables %v", tables),
 				nil,
-			).WithErrSck(err)
-			d.dispatchEvent(EventTablePrivileges, EventLevelError, response.Reply())
+			)
+			d.dispatchEvent(EventTablePrivileges, queryErrLevel(ctx, err), response.Reply())
 			return privs_spec, response.Reply()
 		}
 		privs_spec.Privileges = append(privs_spec.Privileges, priv)
@@ -1268,11 +1369,11 @@ func (d *Datasource) TablePrivs(tables []string, privileges []string) (privs_spe
 	}
 
 	if err := rows.Err(); err != nil {
-		response := wrapify.WrapInternalServerError(
+		response := wrapQueryErr(ctx, err,
 			fmt.Sprintf("An error occurred while iterating privilege results for tables %v", tables),
 			nil,
-		).WithErrSck(err)
-		d.dispatchEvent(EventTablePrivileges, EventLevelError, response.Reply())
+		)
+		d.dispatchEvent(EventTablePrivileges, queryErrLevel(ctx, err), response.Reply())
 		return privs_spec, response.Reply()
 	}
 
@@ -1315,8 +1416,14 @@ func (d *Datasource) TablePrivs(tables []string, privileges []string) (privs_spe
 //   - A TablePrivsSpecMeta containing the list of privileges and statistics.
 //   - A wrapify. R instance that encapsulates either the result or an error message.
 func (d *Datasource) TableAllPrivs(tables ...string) (privs_spec TablePrivsSpecMeta, response wrapify.R) {
+	return d.TableAllPrivsCtx(context.Background(), tables...)
+}
+
+// TableAllPrivsCtx is the context-aware variant of TableAllPrivs: the catalog query is bound to ctx and is abandoned
+// as soon as ctx is cancelled (StatusClientClosedRequest) or its deadline expires (408).
+func (d *Datasource) TableAllPrivsCtx(ctx context.Context, tables ...string) (privs_spec TablePrivsSpecMeta, response wrapify.R) {
 	privileges := []string{"SELECT", "INSERT", "UPDATE", "DELETE", "TRUNCATE", "REFERENCES", "TRIGGER"}
-	return d.TablePrivs(tables, privileges)
+	return d.TablePrivsCtx(ctx, tables, privileges)
 }
 
 // TablePrivsByUser retrieves privileges for specific tables filtered by a specific grantee (user/role).
@@ -1332,6 +1439,12 @@ func (d *Datasource) TableAllPrivs(tables ...string) (privs_spec TablePrivsSpecM
 //   - A TablePrivsSpecMeta containing the list of privileges and statistics.
 //   - A wrapify. R instance that encapsulates either the result or an error message.
 func (d *Datasource) TablePrivsByUser(tables []string, privileges []string, grantee string) (privs_spec TablePrivsSpecMeta, response wrapify.R) {
+	return d.TablePrivsByUserCtx(context.Background(), tables, privileges, grantee)
+}
+
+// TablePrivsByUserCtx is the context-aware variant of TablePrivsByUser: the catalog query is bound to ctx and is abandoned
+// as soon as ctx is cancelled (StatusClientClosedRequest) or its deadline expires (408).
+func (d *Datasource) TablePrivsByUserCtx(ctx context.Context, tables []string, privileges []string, grantee string) (privs_spec TablePrivsSpecMeta, response wrapify.R) {
 	if !d.IsConnected() {
 		return privs_spec, d.State()
 	}
@@ -1371,16 +1484,16 @@ func (d *Datasource) TablePrivsByUser(tables []string, privileges []string, gran
 
 	// Start inspection
 	done := d.Inspect("TablePrivsByUser", query, pq.Array(tables), pq.Array(normalizedPrivileges), grantee)
-	rows, err := d.Conn().Query(query, pq.Array(tables), pq.Array(normalizedPrivileges), grantee)
+	rows, err := d.reader("TablePrivsByUser").QueryContext(ctx, query, pq.Array(tables), pq.Array(normalizedPrivileges), grantee)
 	// End inspection
 	done()
 
 	if err != nil {
-		response := wrapify.WrapInternalServerError(
+		response := wrapQueryErr(ctx, err,
 			fmt.Sprintf("An error occurred while retrieving privileges for tables %v and grantee '%s'", tables, grantee),
 			nil,
-		).WithErrSck(err)
-		d.dispatchEvent(EventTablePrivileges, EventLevelError, response.Reply())
+		)
+		d.dispatchEvent(EventTablePrivileges, queryErrLevel(ctx, err), response.Reply())
 		return privs_spec, response.Reply()
 	}
 	defer rows.Close()
@@ -1391,11 +1504,11 @@ func (d *Datasource) TablePrivsByUser(tables []string, privileges []string, gran
 	for rows.Next() {
 		var priv PrivsDef
 		if err := rows.Scan(&priv.Grantee, &priv.PrivilegeType, &priv.TableName); err != nil {
-			response := wrapify.WrapInternalServerError(
+			response := wrapQueryErr(ctx, err,
 				fmt.Sprintf("An error occurred while scanning privilege results for tables %v", tables),
 				nil,
-			).WithErrSck(err)
-			d.dispatchEvent(EventTablePrivileges, EventLevelError, response.Reply())
+			)
+			d.dispatchEvent(EventTablePrivileges, queryErrLevel(ctx, err), response.Reply())
 			return privs_spec, response.Reply()
 		}
 		privs_spec.Privileges = append(privs_spec.Privileges, priv)
@@ -1403,11 +1516,11 @@ func (d *Datasource) TablePrivsByUser(tables []string, privileges []string, gran
 	}
 
 	if err := rows.Err(); err != nil {
-		response := wrapify.WrapInternalServerError(
+		response := wrapQueryErr(ctx, err,
 			fmt.Sprintf("An error occurred while iterating privilege results for tables %v", tables),
 			nil,
-		).WithErrSck(err)
-		d.dispatchEvent(EventTablePrivileges, EventLevelError, response.Reply())
+		)
+		d.dispatchEvent(EventTablePrivileges, queryErrLevel(ctx, err), response.Reply())
 		return privs_spec, response.Reply()
 	}
 
@@ -1455,6 +1568,12 @@ func (d *Datasource) TablePrivsByUser(tables []string, privileges []string, gran
 //   - A ColExistsSpecMeta containing all check results and statistics.
 //   - A wrapify.R instance that encapsulates either the result or an error message.
 func (d *Datasource) ColsExists(tables []string, columns []string) (ces ColExistsSpecMeta, response wrapify.R) {
+	return d.ColsExistsCtx(context.Background(), tables, columns)
+}
+
+// ColsExistsCtx is the context-aware variant of ColsExists: the catalog query is bound to ctx and is abandoned
+// as soon as ctx is cancelled (StatusClientClosedRequest) or its deadline expires (408).
+func (d *Datasource) ColsExistsCtx(ctx context.Context, tables []string, columns []string) (ces ColExistsSpecMeta, response wrapify.R) {
 	if !d.IsConnected() {
 		return ces, d.State()
 	}
@@ -1496,16 +1615,16 @@ func (d *Datasource) ColsExists(tables []string, columns []string) (ces ColExist
 
 	// Start inspection
 	done := d.Inspect("ColsExists", query, pq.Array(tables), pq.Array(columns))
-	rows, err := d.Conn().Query(query, pq.Array(tables), pq.Array(columns))
+	rows, err := d.reader("ColsExists").QueryContext(ctx, query, pq.Array(tables), pq.Array(columns))
 	// End inspection
 	done()
 
 	if err != nil {
-		response := wrapify.WrapInternalServerError(
+		response := wrapQueryErr(ctx, err,
 			fmt.Sprintf("An error occurred while checking column existence for tables %v and columns %v", tables, columns),
 			nil,
-		).WithErrSck(err)
-		d.dispatchEvent(EventTableColsExists, EventLevelError, response.Reply())
+		)
+		d.dispatchEvent(EventTableColsExists, queryErrLevel(ctx, err), response.Reply())
 		return ces, response.Reply()
 	}
 	defer rows.Close()
@@ -1513,11 +1632,11 @@ func (d *Datasource) ColsExists(tables []string, columns []string) (ces ColExist
 	for rows.Next() {
 		var r ColExistsDef
 		if err := rows.Scan(&r.TableName, &r.ColumnName, &r.Status); err != nil {
-			response := wrapify.WrapInternalServerError(
+			response := wrapQueryErr(ctx, err,
 				fmt.Sprintf("An error occurred while scanning column existence results for tables %v", tables),
 				nil,
-			).WithErrSck(err)
-			d.dispatchEvent(EventTableColsExists, EventLevelError, response.Reply())
+			)
+			d.dispatchEvent(EventTableColsExists, queryErrLevel(ctx, err), response.Reply())
 			return ces, response.Reply()
 		}
 
@@ -1533,11 +1652,11 @@ func (d *Datasource) ColsExists(tables []string, columns []string) (ces ColExist
 	}
 
 	if err := rows.Err(); err != nil {
-		response := wrapify.WrapInternalServerError(
+		response := wrapQueryErr(ctx, err,
 			fmt.Sprintf("An error occurred while iterating column existence results for tables %v", tables),
 			nil,
-		).WithErrSck(err)
-		d.dispatchEvent(EventTableColsExists, EventLevelError, response.Reply())
+		)
+		d.dispatchEvent(EventTableColsExists, queryErrLevel(ctx, err), response.Reply())
 		return ces, response.Reply()
 	}
 
@@ -1569,6 +1688,12 @@ func (d *Datasource) ColsExists(tables []string, columns []string) (ces ColExist
 //   - A ColExistsSpecMeta containing all check results and statistics.
 //   - A wrapify.R instance that encapsulates either the result or an error message.
 func (d *Datasource) ColsExistsIn(schema string, tables []string, columns []string) (ces ColExistsSpecMeta, response wrapify.R) {
+	return d.ColsExistsInCtx(context.Background(), schema, tables, columns)
+}
+
+// ColsExistsInCtx is the context-aware variant of ColsExistsIn: the catalog query is bound to ctx and is abandoned
+// as soon as ctx is cancelled (StatusClientClosedRequest) or its deadline expires (408).
+func (d *Datasource) ColsExistsInCtx(ctx context.Context, schema string, tables []string, columns []string) (ces ColExistsSpecMeta, response wrapify.R) {
 	if !d.IsConnected() {
 		return ces, d.State()
 	}
@@ -1616,16 +1741,16 @@ func (d *Datasource) ColsExistsIn(schema string, tables []string, columns []stri
 
 	// Start inspection
 	done := d.Inspect("ColsExistsIn", query, pq.Array(tables), pq.Array(columns), schema)
-	rows, err := d.Conn().Query(query, pq.Array(tables), pq.Array(columns), schema)
+	rows, err := d.reader("ColsExistsIn").QueryContext(ctx, query, pq.Array(tables), pq.Array(columns), schema)
 	// End inspection
 	done()
 
 	if err != nil {
-		response := wrapify.WrapInternalServerError(
+		response := wrapQueryErr(ctx, err,
 			fmt.Sprintf("An error occurred while checking column existence in schema '%s' for tables %v and columns %v", schema, tables, columns),
 			nil,
-		).WithErrSck(err)
-		d.dispatchEvent(EventTableColsExists, EventLevelError, response.Reply())
+		)
+		d.dispatchEvent(EventTableColsExists, queryErrLevel(ctx, err), response.Reply())
 		return ces, response.Reply()
 	}
 	defer rows.Close()
@@ -1633,11 +1758,11 @@ func (d *Datasource) ColsExistsIn(schema string, tables []string, columns []stri
 	for rows.Next() {
 		var r ColExistsDef
 		if err := rows.Scan(&r.TableName, &r.ColumnName, &r.Status); err != nil {
-			response := wrapify.WrapInternalServerError(
+			response := wrapQueryErr(ctx, err,
 				fmt.Sprintf("An error occurred while scanning column existence results in schema '%s' for tables %v", schema, tables),
 				nil,
-			).WithErrSck(err)
-			d.dispatchEvent(EventTableColsExists, EventLevelError, response.Reply())
+			)
+			d.dispatchEvent(EventTableColsExists, queryErrLevel(ctx, err), response.Reply())
 			return ces, response.Reply()
 		}
 
@@ -1653,11 +1778,11 @@ func (d *Datasource) ColsExistsIn(schema string, tables []string, columns []stri
 	}
 
 	if err := rows.Err(); err != nil {
-		response := wrapify.WrapInternalServerError(
+		response := wrapQueryErr(ctx, err,
 			fmt.Sprintf("An error occurred while iterating column existence results in schema '%s' for tables %v", schema, tables),
 			nil,
-		).WithErrSck(err)
-		d.dispatchEvent(EventTableColsExists, EventLevelError, response.Reply())
+		)
+		d.dispatchEvent(EventTableColsExists, queryErrLevel(ctx, err), response.Reply())
 		return ces, response.Reply()
 	}
 
@@ -1,6 +1,18 @@
 package pgc
 
-import "strings"
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sivaosorg/wrapify"
+)
 
 // isEmpty checks if the provided string is empty or consists solely of whitespace characters.
 //
@@ -50,3 +62,220 @@ func isEmpty(s string) bool {
 func isNotEmpty(s string) bool {
 	return !isEmpty(s)
 }
+
+// sqlState extracts the PostgreSQL SQLSTATE code from an error returned by the lib/pq driver.
+//
+// Parameters:
+//   - `err`: The error to inspect. Wrapped errors are unwrapped using errors.As.
+//
+// Returns:
+//
+//	The five-character SQLSTATE code (e.g. "40001"), or an empty string if the error
+//	does not originate from a *pq.Error.
+func sqlState(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code)
+	}
+	return ""
+}
+
+// isRetryableTxErr reports whether the error indicates that the whole transaction may
+// succeed if it is retried, namely a serialization failure or a detected deadlock.
+func isRetryableTxErr(err error) bool {
+	switch sqlState(err) {
+	case sqlStateSerializationFailure, sqlStateDeadlockDetected:
+		return true
+	default:
+		return false
+	}
+}
+
+// isCachedPlanErr reports whether the error indicates that the cached plan of a prepared
+// statement was invalidated by a schema change, so that preparing it again may succeed.
+// Other feature_not_supported (0A000) errors are not recoverable this way.
+func isCachedPlanErr(err error) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return false
+	}
+	return string(pqErr.Code) == sqlStateFeatureNotSupported && pqErr.Message == msgCachedPlanChanged
+}
+
+// ctxErr reports whether err was caused by the context the query ran under.
+//
+// It recognises context errors surfaced directly by database/sql as well as the
+// query_canceled (57014) error returned by PostgreSQL after lib/pq sent a cancel
+// request on behalf of a done context.
+//
+// Returns:
+//
+//	context.Canceled or context.DeadlineExceeded when the failure is attributable
+//	to ctx, or nil otherwise.
+func ctxErr(ctx context.Context, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, context.Canceled):
+		return context.Canceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return context.DeadlineExceeded
+	case sqlState(err) == sqlStateQueryCanceled && ctx != nil:
+		return ctx.Err()
+	default:
+		return nil
+	}
+}
+
+// wrapQueryErr builds the error response for a query that failed under ctx.
+//
+// A cancelled context is reported as StatusClientClosedRequest (499) and an expired
+// deadline as http.StatusRequestTimeout (408), so callers can tell an abandoned query
+// apart from a database failure. Any other error is classified with ClassifyErr and
+// reported with the status code of its category (e.g. 409 for a unique violation,
+// 503 for a connection failure or an open circuit breaker), the category being attached
+// as the "error_category" debugging key. In every case the error is attached to the
+// response via WithErrSck, together with the sqlstate, constraint, table, column and
+// detail fields of the driver error as debugging keys.
+//
+// Parameters:
+//   - `ctx`: The context the query ran under.
+//   - `err`: The error returned by the driver.
+//   - `message`: The response message.
+//   - `data`: The response body (typically partial results or nil).
+//
+// Returns:
+//
+//	A wrapify.R describing the failure.
+func wrapQueryErr(ctx context.Context, err error, message string, data any) wrapify.R {
+	response := wrapify.New().
+		WithMessage(message).
+		WithBody(data)
+	switch ctxErr(ctx, err) {
+	case context.Canceled:
+		response = response.
+			WithStatusCode(StatusClientClosedRequest).
+			WithDebuggingKV("cancelled", true)
+	case context.DeadlineExceeded:
+		response = response.
+			WithStatusCode(http.StatusRequestTimeout).
+			WithDebuggingKV("deadline_exceeded", true)
+	default:
+		category := ClassifyErr(err)
+		response = response.
+			WithStatusCode(category.StatusCode()).
+			WithDebuggingKV("error_category", category)
+		if errors.Is(err, ErrCircuitOpen) {
+			response = response.WithDebuggingKV("circuit_open", true)
+		}
+	}
+	for key, value := range pqErrFields(err) {
+		response = response.WithDebuggingKV(key, value)
+	}
+	return response.WithErrSck(err).Reply()
+}
+
+// queryErrLevel returns the event level for a query that failed under ctx: EventLevelWarn
+// when the caller abandoned the query through ctx, EventLevelError otherwise.
+func queryErrLevel(ctx context.Context, err error) EventLevel {
+	if ctxErr(ctx, err) != nil {
+		return EventLevelWarn
+	}
+	return EventLevelError
+}
+
+// isScannable reports whether values of type t are decoded with a plain Scan rather than StructScan.
+//
+// This mirrors the rule used by sqlx: non-struct types, types implementing sql.Scanner and structs
+// without exported fields (such as time.Time) are scanned directly from a single column.
+func isScannable(t reflect.Type) bool {
+	if reflect.PointerTo(t).Implements(reflect.TypeFor[sql.Scanner]()) {
+		return true
+	}
+	if t.Kind() != reflect.Struct {
+		return true
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).IsExported() {
+			return false
+		}
+	}
+	return true
+}
+
+// quoteIdent quotes a possibly schema-qualified identifier (e.g. "public.users") so that it can
+// be safely embedded in a generated SQL statement. Each dot-separated part is quoted on its own,
+// following the rules of PostgreSQL's quote_ident: lower-case identifiers that are not keywords
+// are left as-is, anything else is wrapped in double quotes with embedded quotes doubled.
+//
+// Example:
+//
+//	quoteIdent("public.users")  // public.users
+//	quoteIdent("public.Users")  // public."Users"
+//	quoteIdent("order")         // "order"
+func quoteIdent(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		if !isPlainIdent(part) {
+			parts[i] = pq.QuoteIdentifier(part)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// isPlainIdent reports whether s can be used as an identifier without quoting, i.e. it only
+// contains lower-case letters, digits, underscores and dollar signs, does not start with a digit
+// or a dollar sign, and is not a reserved, column-name or type/function-name keyword.
+func isPlainIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r == '_':
+		case (r >= '0' && r <= '9') || r == '$':
+			if i == 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	_, keyword := sqlKeywords[s]
+	return !keyword
+}
+
+// quoteIdents quotes each identifier with quoteIdent and joins them with ", ".
+func quoteIdents(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdent(name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// containsAll reports whether every value of subset is present in set.
+func containsAll(set, subset []string) bool {
+	return len(missingFrom(set, subset)) == 0
+}
+
+// missingFrom returns the values of subset that are not present in set.
+func missingFrom(set, subset []string) []string {
+	var missing []string
+	for _, value := range subset {
+		if !slices.Contains(set, value) {
+			missing = append(missing, value)
+		}
+	}
+	return missing
+}
+
+// backoffDelay returns the delay before the next attempt of an operation that failed its given attempt
+// (1 for the first): base doubled for each previous attempt, capped by maxBackoff.
+func backoffDelay(base, maxBackoff time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
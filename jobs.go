@@ -0,0 +1,668 @@
+package pgc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sivaosorg/loggy"
+	"github.com/sivaosorg/wrapify"
+)
+
+// jobsDDL creates the job queue table and the partial indexes serving the claims, the reclaims and the
+// uniqueness keys, if they do not exist.
+const jobsDDL = `
+	SELECT pg_advisory_xact_lock(hashtext('` + jobsTable + `'));
+	CREATE TABLE IF NOT EXISTS ` + jobsTable + ` (
+		id           bigserial PRIMARY KEY,
+		queue        text NOT NULL,
+		payload      jsonb NOT NULL,
+		priority     integer NOT NULL DEFAULT 0,
+		status       text NOT NULL DEFAULT 'pending',
+		attempts     integer NOT NULL DEFAULT 0,
+		max_attempts integer NOT NULL,
+		unique_key   text,
+		last_error   text,
+		run_at       timestamptz NOT NULL DEFAULT now(),
+		locked_by    text,
+		locked_at    timestamptz,
+		heartbeat_at timestamptz,
+		created_at   timestamptz NOT NULL DEFAULT now(),
+		finished_at  timestamptz
+	);
+	CREATE INDEX IF NOT EXISTS ` + jobsTable + `_pending_idx ON ` + jobsTable + ` (queue, priority DESC, run_at, id) WHERE status = 'pending';
+	CREATE INDEX IF NOT EXISTS ` + jobsTable + `_running_idx ON ` + jobsTable + ` (queue, heartbeat_at) WHERE status = 'running';
+	CREATE UNIQUE INDEX IF NOT EXISTS ` + jobsTable + `_unique_idx ON ` + jobsTable + ` (queue, unique_key)
+		WHERE unique_key IS NOT NULL AND status IN ('pending', 'running');
+`
+
+// EnsureJobs creates the pgc-managed job queue table (pgc_jobs) and its indexes if they do not exist.
+// It is safe to call concurrently from several processes, and is called by JobWorker.Start.
+//
+// Completed and failed jobs are kept in the table, with their last error, for inspection; deleting
+// them is left to the application.
+//
+// Returns:
+//   - A wrapify.R instance describing the outcome.
+func (d *Datasource) EnsureJobs(ctx context.Context) wrapify.R {
+	if !d.IsConnected() {
+		return d.State()
+	}
+	if err := d.execScript(ctx, "Jobs.Setup", jobsDDL); err != nil {
+		response := wrapQueryErr(ctx, err, "An error occurred while creating the job queue table", nil)
+		d.dispatchEvent(EventJobSetup, queryErrLevel(ctx, err), response.Reply())
+		return response.Reply()
+	}
+	response := wrapify.WrapOk(fmt.Sprintf("Job queue table '%s' is ready", jobsTable), nil).Reply()
+	d.dispatchEvent(EventJobSetup, EventLevelSuccess, response)
+	return response
+}
+
+// EnqueueJob inserts a job into queue, to be run by a JobWorker of the queue. The job queue table
+// must exist (see EnsureJobs).
+//
+// A []byte or json.RawMessage payload must hold valid JSON and is stored as is; any other value, including
+// a string, is encoded as JSON. When opts carries a uniqueness key and a pending or running job of the
+// queue has the same key, no job is inserted and the existing job is returned, flagged as a duplicate.
+//
+// Parameters:
+//   - ctx:     The context used to insert the job.
+//   - queue:   The queue of the job.
+//   - payload: The payload of the job.
+//   - opts:    The job options (nil runs the job now, with priority 0 and the default number of attempts).
+//
+// Returns:
+//   - A wrapify.R instance describing the outcome, with the ID of the job as body.
+//
+// Example:
+//
+//	response := ds.EnqueueJob(ctx, "emails", WelcomeEmail{UserID: 42},
+//	    pgc.NewJobOptions().SetDelay(time.Minute).SetUniqueKey("welcome:42"))
+func (d *Datasource) EnqueueJob(ctx context.Context, queue string, payload any, opts *JobOptions) wrapify.R {
+	return enqueueJob(ctx, d, queue, payload, opts)
+}
+
+// EnqueueJob inserts a job into queue within the transaction, so that it only becomes visible to
+// the workers, and runs, if the transaction commits. See Datasource.EnqueueJob.
+func (t *Transaction) EnqueueJob(ctx context.Context, queue string, payload any, opts *JobOptions) wrapify.R {
+	return enqueueJob(ctx, t, queue, payload, opts)
+}
+
+// enqueueJob inserts a job through the inspected execution path of the Executor. A job with a
+// uniqueness key conflicting with a pending or running job is not inserted and the ID of that job is
+// returned instead; when the conflicting job committed after the statement started, its ID is not
+// visible to the statement and the body of the response is nil.
+func enqueueJob(ctx context.Context, exec Executor, queue string, payload any, opts *JobOptions) wrapify.R {
+	ds, ext, response, ok := exec.executor()
+	if !ok {
+		return response
+	}
+	body, err := jsonPayload(payload)
+	if isEmpty(queue) || err != nil {
+		response := wrapify.WrapBadRequest("A queue and a JSON payload are required to enqueue a job", nil).
+			WithDebuggingKV("queue", queue).
+			WithErrSck(err).
+			BindCause().
+			Reply()
+		ds.dispatchEvent(EventJobEnqueue, EventLevelError, response)
+		return response
+	}
+
+	var runAt any
+	if !opts.RunAt().IsZero() {
+		runAt = opts.RunAt()
+	}
+	query := fmt.Sprintf(`
+		WITH inserted AS (
+			INSERT INTO %[1]s (queue, payload, priority, run_at, max_attempts, unique_key)
+			VALUES ($1, $2::jsonb, $3, COALESCE($4::timestamptz, now()), $5, NULLIF($6, ''))
+			ON CONFLICT (queue, unique_key) WHERE unique_key IS NOT NULL AND status IN ('pending', 'running') DO NOTHING
+			RETURNING id, true AS created
+		)
+		SELECT id, created FROM inserted
+		UNION ALL
+		SELECT id, false FROM %[1]s
+		WHERE queue = $1 AND unique_key = NULLIF($6, '') AND status IN ('pending', 'running')
+		LIMIT 1;
+	`, jobsTable)
+	var rows []struct {
+		ID      int64 `db:"id"`
+		Created bool  `db:"created"`
+	}
+	args := []any{queue, body, opts.Priority(), runAt, opts.MaxAttempts(), opts.UniqueKey()}
+	if response := ds.selectContext(ctx, ext, "Jobs.Enqueue", &rows, query, args); response.IsError() {
+		ds.dispatchEvent(EventJobEnqueue, queryErrLevel(ctx, response.Cause()), response)
+		return response
+	}
+
+	if len(rows) == 0 || !rows[0].Created {
+		var id any
+		if len(rows) > 0 {
+			id = rows[0].ID
+		}
+		response = wrapify.WrapOk(fmt.Sprintf("A job with unique key '%s' is already pending or running on queue '%s'", opts.UniqueKey(), queue), id).
+			WithDebuggingKV("id", id).
+			WithDebuggingKV("queue", queue).
+			WithDebuggingKV("unique_key", opts.UniqueKey()).
+			WithDebuggingKV("duplicate", true).
+			WithTotal(0).
+			Reply()
+		ds.dispatchEvent(EventJobEnqueue, EventLevelSuccess, response)
+		return response
+	}
+	id := rows[0].ID
+	response = wrapify.WrapOk(fmt.Sprintf("Enqueued job %d on queue '%s'", id, queue), id).
+		WithDebuggingKV("id", id).
+		WithDebuggingKV("queue", queue).
+		WithDebuggingKV("duplicate", false).
+		WithTotal(1).
+		Reply()
+	ds.dispatchEvent(EventJobEnqueue, EventLevelSuccess, response)
+	return response
+}
+
+// Decode decodes the JSON payload of the job into dest.
+func (j QueuedJob) Decode(dest any) error {
+	return json.Unmarshal(j.Payload, dest)
+}
+
+// NewJobWorker creates a worker running the jobs of queue with handler. Call Start to run it in the background.
+//
+// Jobs are claimed with UPDATE ... WHERE id IN (SELECT ... FOR UPDATE SKIP LOCKED), by priority then
+// scheduled run time, so that several workers, in this or other processes, share a queue without running
+// a job twice concurrently. A claim is a single short statement: the claimed jobs are marked as running
+// and owned by the worker, and no lock is held while they run on the worker pool. Instead, the worker
+// records a heartbeat of its running jobs at every heartbeat interval; a running job without heartbeat for
+// the reclaim delay, such as the jobs of a crashed worker, is reclaimed by any worker of the queue and run
+// again. A job that fails is retried with an exponential backoff, and marked as failed after its last attempt.
+// Delivery is therefore at least once, and handlers should be idempotent.
+//
+// The counters of the worker, including the depth of the queue and the claim latency, are available through
+// Stats. Claims, completions, failures and reclaims are reported as EventJob* events.
+//
+// Parameters:
+//   - ds:      The Datasource owning the job queue table.
+//   - queue:   The queue the worker claims jobs from.
+//   - handler: The function running a job.
+//   - opts:    The worker options (nil applies the defaults).
+//
+// Example:
+//
+//	worker := pgc.NewJobWorker(ds, "emails", func(ctx context.Context, job pgc.QueuedJob) error {
+//	    var email WelcomeEmail
+//	    if err := job.Decode(&email); err != nil {
+//	        return err
+//	    }
+//	    return mailer.Send(ctx, email)
+//	}, pgc.NewJobWorkerOptions().SetConcurrency(8))
+//	if response := worker.Start(ctx); response.IsError() {
+//	    log.Fatal(response.Error())
+//	}
+//	defer worker.Stop()
+func NewJobWorker(ds *Datasource, queue string, handler JobHandler, opts *JobWorkerOptions) *JobWorker {
+	w := &JobWorker{ds: ds, queue: queue, handler: handler, running: make(map[int64]int), freed: make(chan struct{}, 1)}
+	if opts != nil {
+		w.opts = *opts
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	w.id = fmt.Sprintf("%s:%d:%08x", host, os.Getpid(), rand.Uint32())
+	return w
+}
+
+// ID returns the identifier the worker records on the jobs it claims (hostname:pid:random).
+func (w *JobWorker) ID() string {
+	return w.id
+}
+
+// Start creates the job queue table if needed and starts running jobs in the background until ctx is
+// done or Stop is called. Calling Start on a running worker has no effect; a worker stopped by the end
+// of ctx can be started again.
+//
+// Returns:
+//   - A wrapify.R instance describing the outcome.
+func (w *JobWorker) Start(ctx context.Context) wrapify.R {
+	if isEmpty(w.queue) || w.handler == nil {
+		response := wrapify.WrapBadRequest("Queue and handler are required to run jobs", nil).BindCause().Reply()
+		w.ds.dispatchEvent(EventJobClaim, EventLevelError, response)
+		return response
+	}
+	if response := w.ds.EnsureJobs(ctx); response.IsError() {
+		return response
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cancel != nil {
+		select {
+		case <-w.done:
+			// The previous run ended with its parent context and released its pool.
+			w.cancel()
+		default:
+			return wrapify.WrapOk(fmt.Sprintf("Job worker of queue '%s' is already running", w.queue), nil).Reply()
+		}
+	}
+	concurrency := w.opts.Concurrency()
+	poolConf := DefaultPoolConf()
+	poolConf.SetWorkers(concurrency).SetQueueSize(concurrency).SetDropOnFull(false)
+	w.pool = NewPool(poolConf)
+	w.pool.Start()
+	jobCtx, cancelJobs := context.WithCancel(ctx)
+	w.cancelJobs = cancelJobs
+	ctx, w.cancel = context.WithCancel(jobCtx)
+	w.done = make(chan struct{})
+	go w.run(ctx, jobCtx)
+	return wrapify.WrapOk(fmt.Sprintf("Job worker of queue '%s' started", w.queue), nil).
+		WithDebuggingKV("worker", w.id).
+		WithDebuggingKV("concurrency", concurrency).
+		Reply()
+}
+
+// Stop stops claiming jobs and waits, up to the grace period of the worker pool, for the running jobs to
+// complete; the context of the jobs still running is then cancelled. Heartbeats are sent until the running
+// jobs have returned, so that they are not reclaimed meanwhile.
+func (w *JobWorker) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cancel == nil {
+		return
+	}
+	w.cancel()
+	if !w.pool.Stop() {
+		loggy.Warnf("[pgc.jobs] job worker pool shutdown timed out: queue=%s", w.queue)
+	}
+	w.cancelJobs()
+	<-w.done
+	w.cancel, w.cancelJobs, w.pool = nil, nil, nil
+}
+
+// Stats returns the runtime statistics of the worker.
+//
+// Returns:
+//   - JobStats containing the claimed, completed, retried, failed and reclaimed counts, the running jobs,
+//     the depth of the queue as of the last heartbeat, and the claim latencies.
+//
+// Example:
+//
+//	stats := worker.Stats()
+//	loggy.Infof("depth: %d, running: %d, avg latency: %s", stats.Depth, stats.Running, stats.AvgLatency)
+func (w *JobWorker) Stats() JobStats {
+	stats := JobStats{
+		Claimed:     w.claimed.Load(),
+		Completed:   w.completed.Load(),
+		Retried:     w.retried.Load(),
+		Failed:      w.failed.Load(),
+		Reclaimed:   w.reclaimed.Load(),
+		Running:     w.Running(),
+		Depth:       w.depth.Load(),
+		LastLatency: time.Duration(w.lastLatency.Load()),
+	}
+	if stats.Claimed > 0 {
+		stats.AvgLatency = time.Duration(w.totalLatency.Load() / int64(stats.Claimed))
+	}
+	return stats
+}
+
+// Running returns the number of jobs currently run by the worker.
+func (w *JobWorker) Running() int {
+	w.runMu.Lock()
+	defer w.runMu.Unlock()
+	return len(w.running)
+}
+
+// run claims jobs whenever the worker has free slots, as soon as the previous claim filled them, when a
+// job finishes, and after the poll interval otherwise, and sends the heartbeats. Once ctx is done it stops
+// claiming and keeps sending heartbeats until the running jobs have returned or jobCtx is done, then
+// stops the worker pool.
+func (w *JobWorker) run(ctx, jobCtx context.Context) {
+	defer close(w.done)
+	defer w.pool.Stop()
+	heartbeat := time.NewTicker(w.opts.HeartbeatInterval())
+	defer heartbeat.Stop()
+	w.tick(jobCtx)
+
+	claiming := ctx.Done()
+	for {
+		full := false
+		if claiming != nil {
+			if free := w.opts.Concurrency() - w.Running(); free > 0 {
+				claimed, response := w.claim(ctx, jobCtx, free)
+				full = response.IsSuccess() && claimed >= free
+			}
+		} else if w.Running() == 0 || jobCtx.Err() != nil {
+			return
+		}
+		if full {
+			continue
+		}
+		timer := time.NewTimer(w.opts.PollInterval())
+		select {
+		case <-claiming:
+			claiming = nil
+		case <-jobCtx.Done():
+		case <-heartbeat.C:
+			w.tick(jobCtx)
+		case <-w.freed:
+		case <-timer.C:
+		}
+		timer.Stop()
+	}
+}
+
+// claim claims up to limit pending jobs of the queue and submits them to the worker pool.
+//
+// Returns:
+//   - The number of claimed jobs.
+//   - A wrapify.R instance describing the outcome.
+func (w *JobWorker) claim(ctx, jobCtx context.Context, limit int) (int, wrapify.R) {
+	d, ext, response, ok := w.ds.executor()
+	if !ok {
+		return 0, response
+	}
+	query := fmt.Sprintf(`
+		UPDATE %[1]s
+		SET status = 'running', attempts = attempts + 1, locked_by = $2, locked_at = now(), heartbeat_at = now()
+		WHERE id IN (
+			SELECT id
+			FROM %[1]s
+			WHERE queue = $1
+			AND status = 'pending'
+			AND run_at <= now()
+			ORDER BY priority DESC, run_at, id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, queue, payload, priority, attempts, max_attempts, COALESCE(unique_key, '') AS unique_key,
+			run_at, created_at, EXTRACT(EPOCH FROM now() - run_at)::float8 AS latency;
+	`, jobsTable)
+	var rows []jobRow
+	if response := d.selectContext(ctx, ext, "Jobs.Claim", &rows, query, []any{w.queue, w.id, limit}); response.IsError() {
+		d.dispatchEvent(EventJobClaim, queryErrLevel(ctx, response.Cause()), response)
+		return 0, response
+	}
+	if len(rows) == 0 {
+		return 0, wrapify.WrapOk(fmt.Sprintf("No pending job on queue '%s'", w.queue), nil).Reply()
+	}
+
+	ids := make([]int64, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+		latency := time.Duration(max(row.Latency, 0) * float64(time.Second))
+		w.claimed.Add(1)
+		w.lastLatency.Store(int64(latency))
+		w.totalLatency.Add(int64(latency))
+
+		job := QueuedJob{
+			ID:          row.ID,
+			Queue:       row.Queue,
+			Payload:     row.Payload,
+			Priority:    row.Priority,
+			Attempt:     row.Attempts,
+			MaxAttempts: row.MaxAttempts,
+			UniqueKey:   row.UniqueKey,
+			RunAt:       row.RunAt,
+			CreatedAt:   row.CreatedAt,
+		}
+		w.runMu.Lock()
+		w.running[job.ID] = job.Attempt
+		w.runMu.Unlock()
+		if !w.pool.Submit(func() { w.execute(jobCtx, job) }) {
+			w.untrack(job)
+			w.release(job)
+		}
+	}
+	response = wrapify.WrapOk(fmt.Sprintf("Claimed %d jobs on queue '%s'", len(rows), w.queue), nil).
+		WithDebuggingKV("worker", w.id).
+		WithDebuggingKV("ids", ids).
+		WithDebuggingKV("last_latency", time.Duration(w.lastLatency.Load()).String()).
+		WithTotal(len(rows)).
+		Reply()
+	d.dispatchEvent(EventJobClaim, EventLevelSuccess, response)
+	return len(rows), response
+}
+
+// execute runs a claimed job and records its outcome.
+func (w *JobWorker) execute(ctx context.Context, job QueuedJob) {
+	start := time.Now()
+	err := w.safeRun(ctx, job)
+	w.finish(context.WithoutCancel(ctx), job, err, time.Since(start))
+	w.untrack(job)
+	select {
+	case w.freed <- struct{}{}:
+	default:
+	}
+}
+
+// safeRun calls the handler, reporting a panic as an error.
+func (w *JobWorker) safeRun(ctx context.Context, job QueuedJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("pgc: job handler panicked: %v", r)
+		}
+	}()
+	return w.handler(ctx, job)
+}
+
+// finish records the outcome of a job: it is marked as done on success; on failure it is scheduled for a
+// retry with an exponential backoff or, after its last attempt, marked as failed. The outcome is discarded
+// when the claim that ran the job no longer owns it, i.e. it was reclaimed while running. A claim is
+// identified by the worker and the attempt number, so that a run reclaimed and then claimed again by the
+// same worker cannot record the outcome of the later run.
+func (w *JobWorker) finish(ctx context.Context, job QueuedJob, err error, elapsed time.Duration) {
+	d := w.ds
+	event := EventJobComplete
+	var query string
+	var args []any
+	var retryIn time.Duration
+	if err == nil {
+		query = fmt.Sprintf(`
+			UPDATE %s
+			SET status = 'done', last_error = NULL, locked_by = NULL, finished_at = now()
+			WHERE id = $1 AND locked_by = $2 AND attempts = $3 AND status = 'running';
+		`, jobsTable)
+		args = []any{job.ID, w.id, job.Attempt}
+	} else {
+		event = EventJobFail
+		status := JobStatusFailed
+		if job.Attempt < job.MaxAttempts {
+			status = JobStatusPending
+			retryIn = backoffDelay(w.opts.Backoff(), w.opts.MaxBackoff(), job.Attempt)
+		}
+		query = fmt.Sprintf(`
+			UPDATE %s
+			SET status = $3, last_error = $4, locked_by = NULL,
+				run_at = CASE WHEN $3 = 'pending' THEN now() + make_interval(secs => $5) ELSE run_at END,
+				finished_at = CASE WHEN $3 = 'failed' THEN now() END
+			WHERE id = $1 AND locked_by = $2 AND attempts = $6 AND status = 'running';
+		`, jobsTable)
+		args = []any{job.ID, w.id, string(status), err.Error(), retryIn.Seconds(), job.Attempt}
+	}
+
+	affected, response := w.exec(ctx, "Jobs.Finish", query, args...)
+	if response.IsError() {
+		d.dispatchEvent(event, queryErrLevel(ctx, response.Cause()), response)
+		return
+	}
+	if affected == 0 {
+		response := wrapify.WrapOk(fmt.Sprintf("Job %d on queue '%s' returned after it was reclaimed, its outcome is discarded", job.ID, job.Queue), job).
+			WithDebuggingKV("id", job.ID).
+			WithDebuggingKV("queue", job.Queue).
+			WithDebuggingKV("attempt", job.Attempt).
+			WithDebuggingKV("executed_in", elapsed.String()).
+			WithErrSck(err).
+			Reply()
+		d.dispatchEvent(event, EventLevelWarn, response)
+		return
+	}
+
+	switch {
+	case err == nil:
+		w.completed.Add(1)
+		response = wrapify.WrapOk(fmt.Sprintf("Job %d on queue '%s' completed", job.ID, job.Queue), nil).
+			WithDebuggingKV("id", job.ID).
+			WithDebuggingKV("queue", job.Queue).
+			WithDebuggingKV("attempt", job.Attempt).
+			WithDebuggingKV("executed_in", elapsed.String()).
+			Reply()
+		d.dispatchEvent(EventJobComplete, EventLevelSuccess, response)
+	case retryIn > 0:
+		w.retried.Add(1)
+		response = wrapify.WrapInternalServerError(fmt.Sprintf("Job %d on queue '%s' failed attempt %d of %d, retrying in %s", job.ID, job.Queue, job.Attempt, job.MaxAttempts, retryIn), job).
+			WithDebuggingKV("id", job.ID).
+			WithDebuggingKV("queue", job.Queue).
+			WithDebuggingKV("attempt", job.Attempt).
+			WithDebuggingKV("retry_in", retryIn.String()).
+			WithDebuggingKV("executed_in", elapsed.String()).
+			WithErrSck(err).
+			Reply()
+		d.dispatchEvent(EventJobFail, EventLevelWarn, response)
+	default:
+		w.failed.Add(1)
+		response = wrapify.WrapInternalServerError(fmt.Sprintf("Job %d on queue '%s' failed after %d attempts", job.ID, job.Queue, job.Attempt), job).
+			WithDebuggingKV("id", job.ID).
+			WithDebuggingKV("queue", job.Queue).
+			WithDebuggingKV("attempt", job.Attempt).
+			WithDebuggingKV("executed_in", elapsed.String()).
+			WithErrSck(err).
+			Reply()
+		d.dispatchEvent(EventJobFail, EventLevelError, response)
+	}
+}
+
+// release hands a claimed job that could not be submitted to the worker pool, because the worker is
+// stopping, back to the queue without counting the attempt.
+func (w *JobWorker) release(job QueuedJob) {
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET status = 'pending', attempts = attempts - 1, locked_by = NULL
+		WHERE id = $1 AND locked_by = $2 AND attempts = $3 AND status = 'running';
+	`, jobsTable)
+	if _, response := w.exec(context.Background(), "Jobs.Release", query, job.ID, w.id, job.Attempt); response.IsError() {
+		w.ds.dispatchEvent(EventJobClaim, EventLevelError, response)
+	}
+}
+
+// untrack removes a job from the running jobs of the worker, unless the job has been claimed again since.
+func (w *JobWorker) untrack(job QueuedJob) {
+	w.runMu.Lock()
+	if attempt, ok := w.running[job.ID]; ok && attempt == job.Attempt {
+		delete(w.running, job.ID)
+	}
+	w.runMu.Unlock()
+}
+
+// tick sends the heartbeat of the running jobs, reclaims the stale jobs of the queue and samples its depth.
+func (w *JobWorker) tick(ctx context.Context) {
+	w.heartbeat(ctx)
+	w.reclaim(ctx)
+	w.sampleDepth(ctx)
+}
+
+// heartbeat records a heartbeat for the jobs run by the worker, matching each job with the attempt of its claim.
+func (w *JobWorker) heartbeat(ctx context.Context) {
+	w.runMu.Lock()
+	ids := make([]int64, 0, len(w.running))
+	attempts := make([]int64, 0, len(w.running))
+	for id, attempt := range w.running {
+		ids = append(ids, id)
+		attempts = append(attempts, int64(attempt))
+	}
+	w.runMu.Unlock()
+	if len(ids) == 0 {
+		return
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE %s
+		SET heartbeat_at = now()
+		WHERE (id, attempts) IN (SELECT * FROM unnest($1::bigint[], $3::integer[]))
+		AND locked_by = $2 AND status = 'running';
+	`, jobsTable)
+	if _, response := w.exec(WithWriteRetry(ctx), "Jobs.Heartbeat", query, pq.Array(ids), w.id, pq.Array(attempts)); response.IsError() {
+		err := response.Cause()
+		response := wrapify.WrapServiceUnavailable(fmt.Sprintf("Failed to record the heartbeat of %d jobs on queue '%s', they may be reclaimed", len(ids), w.queue), nil).
+			WithDebuggingKV("worker", w.id).
+			WithDebuggingKV("ids", ids).
+			WithErrSck(err).
+			Reply()
+		w.ds.dispatchEvent(EventJobHeartbeat, queryErrLevel(ctx, err), response)
+	}
+}
+
+// reclaim hands the running jobs of the queue without heartbeat for the reclaim delay back to the queue,
+// or marks them as failed after their last attempt.
+func (w *JobWorker) reclaim(ctx context.Context) {
+	d, ext, response, ok := w.ds.executor()
+	if !ok {
+		return
+	}
+	query := fmt.Sprintf(`
+		UPDATE %[1]s
+		SET status = CASE WHEN attempts >= max_attempts THEN 'failed' ELSE 'pending' END,
+			last_error = 'pgc: job reclaimed after its worker stopped sending heartbeats',
+			locked_by = NULL,
+			run_at = now(),
+			finished_at = CASE WHEN attempts >= max_attempts THEN now() END
+		WHERE id IN (
+			SELECT id
+			FROM %[1]s
+			WHERE queue = $1
+			AND status = 'running'
+			AND heartbeat_at < now() - make_interval(secs => $2)
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id;
+	`, jobsTable)
+	var ids []int64
+	if response = d.selectContext(ctx, ext, "Jobs.Reclaim", &ids, query, []any{w.queue, w.opts.ReclaimAfter().Seconds()}); response.IsError() {
+		d.dispatchEvent(EventJobReclaim, queryErrLevel(ctx, response.Cause()), response)
+		return
+	}
+	if len(ids) == 0 {
+		return
+	}
+	w.reclaimed.Add(uint64(len(ids)))
+	response = wrapify.WrapOk(fmt.Sprintf("Reclaimed %d jobs on queue '%s' without heartbeat for %s", len(ids), w.queue, w.opts.ReclaimAfter()), nil).
+		WithDebuggingKV("worker", w.id).
+		WithDebuggingKV("ids", ids).
+		WithTotal(len(ids)).
+		Reply()
+	d.dispatchEvent(EventJobReclaim, EventLevelWarn, response)
+}
+
+// sampleDepth records the number of jobs of the queue ready to run.
+func (w *JobWorker) sampleDepth(ctx context.Context) {
+	d, ext, _, ok := w.ds.executor()
+	if !ok {
+		return
+	}
+	var depth int64
+	query := fmt.Sprintf("SELECT count(*) FROM %s WHERE queue = $1 AND status = 'pending' AND run_at <= now();", jobsTable)
+	if response := d.getContext(ctx, ext, "Jobs.Depth", &depth, query, []any{w.queue}); response.IsSuccess() {
+		w.depth.Store(depth)
+	}
+}
+
+// exec executes a statement of the worker on the connection pool of the Datasource.
+//
+// Returns:
+//   - The number of affected rows.
+//   - A wrapify.R instance describing the outcome.
+func (w *JobWorker) exec(ctx context.Context, funcName, query string, args ...any) (int64, wrapify.R) {
+	d, ext, response, ok := w.ds.executor()
+	if !ok {
+		return 0, response
+	}
+	result, response := d.execContext(ctx, ext, funcName, query, args)
+	if response.IsError() {
+		return 0, response
+	}
+	affected, _ := result.RowsAffected()
+	return affected, response
+}
@@ -0,0 +1,314 @@
+package pgc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sivaosorg/loggy"
+	"github.com/sivaosorg/wrapify"
+)
+
+// Listen subscribes handler to the notifications sent on channel (LISTEN channel).
+//
+// Notifications are received on a dedicated connection opened with the settings of the Datasource
+// on the first subscription. When that connection is lost it is re-established on its own, with a
+// delay doubling from one second up to the ping interval, and every channel is listened to again;
+// notifications sent while it was down are lost. The connection state changes are reported as
+// EventListenerConnect, EventListenerDisconnect and EventListenerReconnect events.
+//
+// Handlers run on a worker pool, so that a slow handler cannot block the listener; as a consequence,
+// the notifications of a channel may be handled concurrently and out of order. The pool is configured
+// through the ListenerPoolConf of the settings; by default, notifications received while its queue is
+// full are dropped. Since NOTIFY has no redelivery, every dropped notification is reported as an
+// EventListenerDrop event and counted in ListenerStats. Subscribing to a channel that is already
+// listened to replaces its handler.
+//
+// Parameters:
+//   - channel: The channel name (an identifier, case-sensitive as given).
+//   - handler: The function handling the notifications of the channel.
+//
+// Returns:
+//   - A wrapify.R instance describing the outcome.
+//
+// Example:
+//
+//	response := ds.Listen("orders_created", func(n pgc.Notification) {
+//	    log.Printf("order created: %s", n.Payload)
+//	})
+func (d *Datasource) Listen(channel string, handler NotificationHandler) wrapify.R {
+	if !d.IsConnected() {
+		return d.State()
+	}
+	if isEmpty(channel) || handler == nil {
+		response := wrapify.WrapBadRequest("Channel name and handler are required to listen to notifications", nil).BindCause().Reply()
+		d.dispatchEvent(EventListenerNotify, EventLevelError, response)
+		return response
+	}
+
+	l := d.notifyListener()
+	l.mu.Lock()
+	_, listening := l.handlers[channel]
+	l.handlers[channel] = handler
+	l.mu.Unlock()
+	if listening {
+		return wrapify.WrapOk(fmt.Sprintf("Replaced the handler of channel '%s'", channel), nil).
+			WithDebuggingKV("channel", channel).
+			Reply()
+	}
+
+	if err := l.conn.Listen(channel); err != nil && !errors.Is(err, pq.ErrChannelAlreadyOpen) {
+		l.mu.Lock()
+		delete(l.handlers, channel)
+		l.mu.Unlock()
+		response := wrapQueryErr(context.Background(), err, fmt.Sprintf("Failed to listen to channel '%s'", channel), nil)
+		d.dispatchEvent(EventListenerNotify, EventLevelError, response.Reply())
+		return response.Reply()
+	}
+	return wrapify.WrapOk(fmt.Sprintf("Listening to channel '%s'", channel), nil).
+		WithDebuggingKV("channel", channel).
+		Reply()
+}
+
+// ListenAs subscribes handler to the notifications sent on channel, decoding their JSON payload
+// (as sent by Notify) into a value of type T. Notifications whose payload cannot be decoded are
+// skipped and reported as EventListenerNotify errors. See Datasource.Listen for the delivery semantics.
+//
+// Example:
+//
+//	type OrderCreated struct {
+//	    ID    int64  `json:"id"`
+//	    Email string `json:"email"`
+//	}
+//	response := pgc.ListenAs(ds, "orders_created", func(order OrderCreated, n pgc.Notification) {
+//	    log.Printf("order %d created for %s", order.ID, order.Email)
+//	})
+func ListenAs[T any](d *Datasource, channel string, handler func(payload T, n Notification)) wrapify.R {
+	if handler == nil {
+		return d.Listen(channel, nil)
+	}
+	return d.Listen(channel, func(n Notification) {
+		var payload T
+		if err := json.Unmarshal([]byte(n.Payload), &payload); err != nil {
+			response := wrapify.WrapBadRequest(fmt.Sprintf("Failed to decode the payload of a notification on channel '%s'", n.Channel), n).
+				WithDebuggingKV("channel", n.Channel).
+				WithErrSck(err).
+				Reply()
+			d.dispatchEvent(EventListenerNotify, EventLevelError, response)
+			return
+		}
+		handler(payload, n)
+	})
+}
+
+// Unlisten unsubscribes from the notifications sent on channel (UNLISTEN channel).
+//
+// Returns:
+//   - A wrapify.R instance describing the outcome; a not found response when the channel
+//     is not listened to.
+func (d *Datasource) Unlisten(channel string) wrapify.R {
+	d.mu.RLock()
+	l := d.listener
+	d.mu.RUnlock()
+
+	var listening bool
+	if l != nil {
+		l.mu.Lock()
+		_, listening = l.handlers[channel]
+		delete(l.handlers, channel)
+		l.mu.Unlock()
+	}
+	if !listening {
+		return wrapify.WrapNotFound(fmt.Sprintf("Channel '%s' is not listened to", channel), nil).BindCause().Reply()
+	}
+	if err := l.conn.Unlisten(channel); err != nil && !errors.Is(err, pq.ErrChannelNotOpen) {
+		response := wrapQueryErr(context.Background(), err, fmt.Sprintf("Failed to unlisten from channel '%s'", channel), nil)
+		d.dispatchEvent(EventListenerNotify, EventLevelError, response.Reply())
+		return response.Reply()
+	}
+	return wrapify.WrapOk(fmt.Sprintf("Stopped listening to channel '%s'", channel), nil).
+		WithDebuggingKV("channel", channel).
+		Reply()
+}
+
+// Notify sends a notification on channel (pg_notify). A string or []byte payload is sent as is; any
+// other value, such as a struct, is encoded as JSON, so that it can be decoded by ListenAs. A nil
+// payload sends a notification without payload. The encoded payload must be shorter than 8000 bytes.
+//
+// Parameters:
+//   - ctx:     The context used to send the notification.
+//   - channel: The channel name.
+//   - payload: The payload of the notification.
+//
+// Returns:
+//   - A wrapify.R instance describing the outcome.
+//
+// Example:
+//
+//	response := ds.Notify(ctx, "orders_created", OrderCreated{ID: 42, Email: "jane@example.com"})
+func (d *Datasource) Notify(ctx context.Context, channel string, payload any) wrapify.R {
+	return notify(ctx, d, channel, payload)
+}
+
+// Notify sends a notification on channel within the transaction; it is delivered to the listeners
+// when the transaction commits, and discarded if it rolls back. See Datasource.Notify.
+func (t *Transaction) Notify(ctx context.Context, channel string, payload any) wrapify.R {
+	return notify(ctx, t, channel, payload)
+}
+
+// notify encodes the payload and sends it on channel through the inspected execution path of the Executor.
+func notify(ctx context.Context, exec Executor, channel string, payload any) wrapify.R {
+	ds, ext, response, ok := exec.executor()
+	if !ok {
+		return response
+	}
+	if isEmpty(channel) {
+		response := wrapify.WrapBadRequest("Channel name is required to send a notification", nil).BindCause().Reply()
+		ds.dispatchEvent(EventListenerNotify, EventLevelError, response)
+		return response
+	}
+	text, err := notifyPayload(payload)
+	if err == nil && len(text) >= notifyPayloadLimit {
+		err = fmt.Errorf("pgc: notification payload of %d bytes exceeds the limit of %d bytes", len(text), notifyPayloadLimit-1)
+	}
+	if err != nil {
+		response := wrapify.WrapBadRequest(fmt.Sprintf("Invalid payload for a notification on channel '%s'", channel), nil).
+			WithDebuggingKV("channel", channel).
+			WithErrSck(err).
+			Reply()
+		ds.dispatchEvent(EventListenerNotify, EventLevelError, response)
+		return response
+	}
+	_, response = ds.execContext(ctx, ext, "Notify", "SELECT pg_notify($1, $2)", []any{channel, text})
+	return response
+}
+
+// notifyPayload returns the text sent as the payload of a notification.
+func notifyPayload(payload any) (string, error) {
+	switch v := payload.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case []byte:
+		return string(v), nil
+	case json.RawMessage:
+		return string(v), nil
+	}
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// notifyListener returns the listener of the Datasource, opening it on first use.
+func (d *Datasource) notifyListener() *notifyListener {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.listener != nil {
+		return d.listener
+	}
+
+	maxReconnect := d.conf.PingInterval()
+	if maxReconnect <= 0 {
+		maxReconnect = defaultPingInterval
+	}
+	l := &notifyListener{
+		handlers: make(map[string]NotificationHandler),
+		pool:     NewPool(d.conf.ListenerPoolConf()),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	l.conn = pq.NewListener(d.conf.String(false), min(defaultListenerMinReconnect, maxReconnect), maxReconnect, d.listenerEvent)
+	l.pool.Start()
+	go d.deliver(l, maxReconnect)
+	d.listener = l
+	return l
+}
+
+// listenerEvent reports the connection state changes of the listener as events.
+func (d *Datasource) listenerEvent(event pq.ListenerEventType, err error) {
+	name := d.conf.ConnString()
+	switch event {
+	case pq.ListenerEventConnected:
+		response := wrapify.WrapOk(fmt.Sprintf("Listener connected to '%s'", name), nil).Reply()
+		d.dispatchEvent(EventListenerConnect, EventLevelSuccess, response)
+	case pq.ListenerEventDisconnected:
+		response := wrapify.WrapServiceUnavailable(fmt.Sprintf("Listener disconnected from '%s', notifications may be lost until it reconnects", name), nil).
+			WithErrSck(err).
+			Reply()
+		d.dispatchEvent(EventListenerDisconnect, EventLevelWarn, response)
+	case pq.ListenerEventReconnected:
+		response := wrapify.WrapOk(fmt.Sprintf("Listener reconnected to '%s'", name), nil).Reply()
+		d.dispatchEvent(EventListenerReconnect, EventLevelSuccess, response)
+	case pq.ListenerEventConnectionAttemptFailed:
+		response := wrapify.WrapServiceUnavailable(fmt.Sprintf("Listener failed to reconnect to '%s'", name), nil).
+			WithErrSck(err).
+			Reply()
+		d.dispatchEvent(EventListenerReconnect, EventLevelError, response)
+	}
+}
+
+// deliver hands the received notifications over to the worker pool of the listener until it is
+// closed, and pings the listener connection whenever it stayed idle for a ping interval so that
+// a broken connection is noticed.
+func (d *Datasource) deliver(l *notifyListener, interval time.Duration) {
+	defer close(l.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			go l.conn.Ping()
+		case n, ok := <-l.conn.NotificationChannel():
+			if !ok {
+				return
+			}
+			if n == nil {
+				// A nil notification follows a reconnection; it is reported by listenerEvent.
+				continue
+			}
+			ticker.Reset(interval)
+			l.mu.RLock()
+			handler := l.handlers[n.Channel]
+			l.mu.RUnlock()
+			if handler == nil {
+				continue
+			}
+			notification := Notification{Channel: n.Channel, Payload: n.Extra, PID: n.BePid}
+			if !l.pool.Submit(func() { handler(notification) }) {
+				loggy.Warnf("[pgc.listener] notification dropped due to full queue: channel=%s", n.Channel)
+				response := wrapify.WrapServiceUnavailable(fmt.Sprintf("Dropped a notification on channel '%s', the handler queue is full", n.Channel), notification).
+					WithDebuggingKV("channel", n.Channel).
+					WithDebuggingKV("dropped", l.pool.Stats().Dropped).
+					Reply()
+				d.dispatchEvent(EventListenerDrop, EventLevelError, response)
+			}
+		}
+	}
+}
+
+// closeListener closes the listener connection, if any, and waits for the pending handlers to finish.
+func (d *Datasource) closeListener() error {
+	d.mu.Lock()
+	l := d.listener
+	d.listener = nil
+	d.mu.Unlock()
+	if l == nil {
+		return nil
+	}
+
+	close(l.stop)
+	err := l.conn.Close()
+	<-l.done
+	if !l.pool.Stop() {
+		loggy.Warn("[pgc.listener] listener pool shutdown timed out")
+	}
+	return err
+}
@@ -0,0 +1,387 @@
+package pgc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sivaosorg/wrapify"
+)
+
+// outboxDDL creates the outbox table and the partial index serving the claims, if they do not exist.
+const outboxDDL = `
+	SELECT pg_advisory_xact_lock(hashtext('` + outboxTable + `'));
+	CREATE TABLE IF NOT EXISTS ` + outboxTable + ` (
+		id            bigserial PRIMARY KEY,
+		topic         text NOT NULL,
+		payload       jsonb NOT NULL,
+		status        text NOT NULL DEFAULT 'pending',
+		attempts      integer NOT NULL DEFAULT 0,
+		last_error    text,
+		available_at  timestamptz NOT NULL DEFAULT now(),
+		created_at    timestamptz NOT NULL DEFAULT now(),
+		dispatched_at timestamptz
+	);
+	CREATE INDEX IF NOT EXISTS ` + outboxTable + `_pending_idx ON ` + outboxTable + ` (available_at, id) WHERE status = 'pending';
+`
+
+// EnsureOutbox creates the pgc-managed outbox table (pgc_outbox) and its index if they do not exist.
+// It is safe to call concurrently from several processes, and is called by OutboxDispatcher.Start.
+//
+// Returns:
+//   - A wrapify.R instance describing the outcome.
+func (d *Datasource) EnsureOutbox(ctx context.Context) wrapify.R {
+	if !d.IsConnected() {
+		return d.State()
+	}
+	if err := d.execScript(ctx, "Outbox.Setup", outboxDDL); err != nil {
+		response := wrapQueryErr(ctx, err, "An error occurred while creating the outbox table", nil)
+		d.dispatchEvent(EventOutboxSetup, queryErrLevel(ctx, err), response.Reply())
+		return response.Reply()
+	}
+	response := wrapify.WrapOk(fmt.Sprintf("Outbox table '%s' is ready", outboxTable), nil).Reply()
+	d.dispatchEvent(EventOutboxSetup, EventLevelSuccess, response)
+	return response
+}
+
+// Enqueue inserts a message into the outbox table within the transaction, so that it is published by
+// an OutboxDispatcher if, and only if, the transaction commits. It is the context-free variant of EnqueueCtx.
+func (t *Transaction) Enqueue(topic string, payload any) wrapify.R {
+	return t.EnqueueCtx(context.Background(), topic, payload)
+}
+
+// EnqueueCtx inserts a message into the outbox table within the transaction, so that it is published by
+// an OutboxDispatcher if, and only if, the transaction commits. The outbox table must exist (see EnsureOutbox).
+//
+// A []byte or json.RawMessage payload must hold valid JSON and is stored as is; any other value, including
+// a string, is encoded as JSON.
+//
+// Parameters:
+//   - ctx:     The context used to insert the message.
+//   - topic:   The topic the message is published to.
+//   - payload: The payload of the message.
+//
+// Returns:
+//   - A wrapify.R instance describing the outcome, with the ID of the message as body.
+//
+// Example:
+//
+//	response := ds.WithTx(ctx, nil, func(tx *pgc.Transaction) error {
+//	    if _, response := tx.ExecCtx(ctx, "INSERT INTO orders (id, total) VALUES ($1, $2)", order.ID, order.Total); response.IsError() {
+//	        return response.Cause()
+//	    }
+//	    return tx.EnqueueCtx(ctx, "orders.created", order).Cause()
+//	})
+func (t *Transaction) EnqueueCtx(ctx context.Context, topic string, payload any) wrapify.R {
+	ds, ext, response, ok := t.executor()
+	if !ok {
+		return response
+	}
+	body, err := jsonPayload(payload)
+	if isEmpty(topic) || err != nil {
+		response := wrapify.WrapBadRequest("A topic and a JSON payload are required to enqueue an outbox message", nil).
+			WithDebuggingKV("topic", topic).
+			WithErrSck(err).
+			BindCause().
+			Reply()
+		ds.dispatchEvent(EventOutboxEnqueue, EventLevelError, response)
+		return response
+	}
+
+	var id int64
+	query := fmt.Sprintf("INSERT INTO %s (topic, payload) VALUES ($1, $2::jsonb) RETURNING id;", outboxTable)
+	if response := ds.getContext(ctx, ext, "Outbox.Enqueue", &id, query, []any{topic, body}); response.IsError() {
+		ds.dispatchEvent(EventOutboxEnqueue, queryErrLevel(ctx, response.Cause()), response)
+		return response
+	}
+	response = wrapify.WrapOk(fmt.Sprintf("Enqueued outbox message %d on topic '%s'", id, topic), id).
+		WithDebuggingKV("id", id).
+		WithDebuggingKV("topic", topic).
+		WithTotal(1).
+		Reply()
+	ds.dispatchEvent(EventOutboxEnqueue, EventLevelSuccess, response)
+	return response
+}
+
+// NewOutboxDispatcher creates a dispatcher publishing the messages of the outbox table of ds with publisher.
+// Call Start to run it in the background, or Dispatch to publish a single batch.
+//
+// Messages are claimed in batches with SELECT ... FOR UPDATE SKIP LOCKED, so that several dispatchers,
+// in this or other processes, share the work without publishing a message twice concurrently. The messages
+// of a batch are published concurrently on a worker pool, within the transaction holding their locks;
+// published messages are then marked as done, and failed ones are scheduled for a retry with an exponential
+// backoff or, after their last attempt, dead-lettered. Delivery is at least once: a message whose outcome
+// cannot be recorded (e.g. the connection is lost after publishing) is published again.
+//
+// Every batch is reported as an EventOutboxDispatch event, with the number of published, failed and
+// dead-lettered messages and the throughput, and every dead-lettered message as an EventOutboxDeadLetter event.
+//
+// Parameters:
+//   - ds:        The Datasource owning the outbox table.
+//   - publisher: The function publishing a message.
+//   - opts:      The dispatcher options (nil applies the defaults).
+//
+// Example:
+//
+//	dispatcher := pgc.NewOutboxDispatcher(ds, func(ctx context.Context, msg pgc.OutboxMessage) error {
+//	    return broker.Publish(ctx, msg.Topic, msg.Payload)
+//	}, pgc.NewOutboxOptions().SetMaxAttempts(10))
+//	if response := dispatcher.Start(ctx); response.IsError() {
+//	    log.Fatal(response.Error())
+//	}
+//	defer dispatcher.Stop()
+func NewOutboxDispatcher(ds *Datasource, publisher OutboxPublisher, opts *OutboxOptions) *OutboxDispatcher {
+	o := &OutboxDispatcher{ds: ds, publisher: publisher}
+	if opts != nil {
+		o.opts = *opts
+	}
+	return o
+}
+
+// Start creates the outbox table if needed and starts dispatching in the background until ctx is done
+// or Stop is called. A batch is claimed as soon as the previous one was full, and after the poll
+// interval otherwise. Calling Start on a running dispatcher has no effect; a dispatcher stopped by
+// the end of ctx can be started again.
+//
+// Returns:
+//   - A wrapify.R instance describing the outcome.
+func (o *OutboxDispatcher) Start(ctx context.Context) wrapify.R {
+	if o.publisher == nil {
+		response := wrapify.WrapBadRequest("Publisher is required to dispatch the outbox", nil).BindCause().Reply()
+		o.ds.dispatchEvent(EventOutboxDispatch, EventLevelError, response)
+		return response
+	}
+	if response := o.ds.EnsureOutbox(ctx); response.IsError() {
+		return response
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.cancel != nil {
+		select {
+		case <-o.done:
+			// The previous run ended with its parent context and released its pool.
+			o.cancel()
+		default:
+			return wrapify.WrapOk("Outbox dispatcher is already running", nil).Reply()
+		}
+	}
+	poolConf := DefaultPoolConf()
+	poolConf.SetWorkers(o.opts.Workers()).SetQueueSize(o.opts.BatchSize()).SetDropOnFull(false)
+	o.pool = NewPool(poolConf)
+	o.pool.Start()
+	ctx, o.cancel = context.WithCancel(ctx)
+	o.done = make(chan struct{})
+	go o.run(ctx)
+	return wrapify.WrapOk("Outbox dispatcher started", nil).
+		WithDebuggingKV("batch_size", o.opts.BatchSize()).
+		WithDebuggingKV("workers", o.opts.Workers()).
+		Reply()
+}
+
+// Stop stops dispatching, waiting for the batch in progress to complete.
+func (o *OutboxDispatcher) Stop() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.cancel == nil {
+		return
+	}
+	o.cancel()
+	<-o.done
+	o.pool.Stop()
+	o.cancel, o.pool = nil, nil
+}
+
+// run dispatches batches until ctx is done, then stops the worker pool.
+func (o *OutboxDispatcher) run(ctx context.Context) {
+	defer close(o.done)
+	defer o.pool.Stop()
+	for ctx.Err() == nil {
+		claimed, response := o.Dispatch(ctx)
+		if response.IsSuccess() && claimed >= o.opts.BatchSize() {
+			continue
+		}
+		timer := time.NewTimer(o.opts.PollInterval())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// Dispatch claims a batch of pending messages, publishes them and records their outcome.
+//
+// Returns:
+//   - The number of claimed messages.
+//   - A wrapify.R instance describing the outcome, with the number of published messages as total.
+func (o *OutboxDispatcher) Dispatch(ctx context.Context) (int, wrapify.R) {
+	d := o.ds
+	if o.publisher == nil {
+		response := wrapify.WrapBadRequest("Publisher is required to dispatch the outbox", nil).BindCause().Reply()
+		d.dispatchEvent(EventOutboxDispatch, EventLevelError, response)
+		return 0, response
+	}
+
+	start := time.Now()
+	var claimed, published int
+	var failed, dead []int64
+	var letters []deadLetter
+	response := d.WithTx(ctx, nil, func(tx *Transaction) error {
+		claimed, published, failed, dead, letters = 0, 0, nil, nil, nil
+		query := fmt.Sprintf(`
+			SELECT id, topic, payload, attempts, created_at
+			FROM %s
+			WHERE status = 'pending'
+			AND available_at <= now()
+			ORDER BY id
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED;
+		`, outboxTable)
+		var rows []outboxRow
+		if response := tx.SelectCtx(ctx, &rows, query, o.opts.BatchSize()); response.IsError() {
+			return response.Cause()
+		}
+		claimed = len(rows)
+		if claimed == 0 {
+			return nil
+		}
+
+		messages := make([]OutboxMessage, len(rows))
+		for i, row := range rows {
+			messages[i] = OutboxMessage{ID: row.ID, Topic: row.Topic, Payload: row.Payload, Attempts: row.Attempts, CreatedAt: row.CreatedAt}
+		}
+		var done []int64
+		for i, err := range o.publish(ctx, messages) {
+			msg := messages[i]
+			if err == nil {
+				done = append(done, msg.ID)
+				continue
+			}
+			attempts := msg.Attempts + 1
+			status := OutboxStatusPending
+			if attempts >= o.opts.MaxAttempts() {
+				status = OutboxStatusDead
+				dead = append(dead, msg.ID)
+			} else {
+				failed = append(failed, msg.ID)
+			}
+			query := fmt.Sprintf(`
+				UPDATE %s
+				SET status = $2, attempts = $3, last_error = $4, available_at = now() + make_interval(secs => $5)
+				WHERE id = $1;
+			`, outboxTable)
+			if _, response := tx.ExecCtx(ctx, query, msg.ID, string(status), attempts, err.Error(), o.delay(attempts).Seconds()); response.IsError() {
+				return response.Cause()
+			}
+			if status == OutboxStatusDead {
+				letters = append(letters, deadLetter{msg: msg, attempts: attempts, err: err})
+			}
+		}
+		if len(done) > 0 {
+			query := fmt.Sprintf(`
+				UPDATE %s
+				SET status = 'done', attempts = attempts + 1, last_error = NULL, dispatched_at = now()
+				WHERE id = ANY($1);
+			`, outboxTable)
+			if _, response := tx.ExecCtx(ctx, query, pq.Array(done)); response.IsError() {
+				return response.Cause()
+			}
+		}
+		published = len(done)
+		return nil
+	})
+	if response.IsError() {
+		d.dispatchEvent(EventOutboxDispatch, queryErrLevel(ctx, response.Cause()), response)
+		return claimed, response
+	}
+	if claimed == 0 {
+		return 0, wrapify.WrapOk("No pending outbox message", nil).Reply()
+	}
+	// Dead letters are only reported once the transaction recording them has committed.
+	for _, letter := range letters {
+		msg := letter.msg
+		response := wrapify.WrapInternalServerError(fmt.Sprintf("Outbox message %d on topic '%s' is dead-lettered after %d attempts", msg.ID, msg.Topic, letter.attempts), msg).
+			WithDebuggingKV("id", msg.ID).
+			WithDebuggingKV("topic", msg.Topic).
+			WithDebuggingKV("attempts", letter.attempts).
+			WithErrSck(letter.err).
+			Reply()
+		d.dispatchEvent(EventOutboxDeadLetter, EventLevelError, response)
+	}
+
+	elapsed := time.Since(start)
+	level := EventLevelSuccess
+	if len(failed) > 0 || len(dead) > 0 {
+		level = EventLevelWarn
+	}
+	response = wrapify.WrapOk(fmt.Sprintf("Dispatched %d outbox messages: %d published, %d failed, %d dead-lettered", claimed, published, len(failed), len(dead)), nil).
+		WithDebuggingKV("published", published).
+		WithDebuggingKV("failed", failed).
+		WithDebuggingKV("dead_lettered", dead).
+		WithDebuggingKV("executed_in", elapsed.String()).
+		WithDebuggingKV("throughput_per_sec", float64(claimed)/max(elapsed.Seconds(), 1e-9)).
+		WithTotal(published).
+		Reply()
+	d.dispatchEvent(EventOutboxDispatch, level, response)
+	return claimed, response
+}
+
+// publish publishes the messages concurrently on the worker pool of the dispatcher (or sequentially when
+// it is not started) and returns the outcome of each message.
+func (o *OutboxDispatcher) publish(ctx context.Context, messages []OutboxMessage) []error {
+	errs := make([]error, len(messages))
+	var wg sync.WaitGroup
+	for i, msg := range messages {
+		wg.Add(1)
+		job := func() {
+			defer wg.Done()
+			errs[i] = o.safePublish(ctx, msg)
+		}
+		if o.pool == nil || !o.pool.Submit(job) {
+			job()
+		}
+	}
+	wg.Wait()
+	return errs
+}
+
+// safePublish calls the publisher, reporting a panic as an error.
+func (o *OutboxDispatcher) safePublish(ctx context.Context, msg OutboxMessage) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("pgc: outbox publisher panicked: %v", r)
+		}
+	}()
+	return o.publisher(ctx, msg)
+}
+
+// delay returns the delay before the next attempt of a message that failed its given attempt (1 for the first):
+// the backoff doubled for each previous attempt, capped by the maximum backoff.
+func (o *OutboxDispatcher) delay(attempts int) time.Duration {
+	return backoffDelay(o.opts.Backoff(), o.opts.MaxBackoff(), attempts)
+}
+
+// jsonPayload returns the JSON text stored as the payload of an outbox message or a job.
+func jsonPayload(payload any) (string, error) {
+	var b []byte
+	switch v := payload.(type) {
+	case json.RawMessage:
+		b = v
+	case []byte:
+		b = v
+	default:
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return "", err
+		}
+		return string(encoded), nil
+	}
+	if !json.Valid(b) {
+		return "", fmt.Errorf("pgc: payload is not valid JSON")
+	}
+	return string(b), nil
+}
@@ -0,0 +1,375 @@
+package pgc
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sivaosorg/wrapify"
+)
+
+// Asc returns an ascending sort key on the given column. NULL values sort last,
+// as with PostgreSQL's default ordering.
+func Asc(column string) SortKey {
+	return SortKey{column: column}
+}
+
+// Desc returns a descending sort key on the given column. NULL values sort first,
+// as with PostgreSQL's default ordering.
+func Desc(column string) SortKey {
+	return SortKey{column: column, desc: true, nullsFirst: true}
+}
+
+// NullsFirst returns a copy of the sort key that places NULL values before any other value.
+func (k SortKey) NullsFirst() SortKey {
+	k.nullsFirst = true
+	return k
+}
+
+// NullsLast returns a copy of the sort key that places NULL values after any other value.
+func (k SortKey) NullsLast() SortKey {
+	k.nullsFirst = false
+	return k
+}
+
+// String renders the sort key as an ORDER BY item, e.g. "created_at DESC NULLS FIRST".
+func (k SortKey) String() string {
+	var b strings.Builder
+	b.WriteString(quoteIdent(k.column))
+	if k.desc {
+		b.WriteString(" DESC")
+	} else {
+		b.WriteString(" ASC")
+	}
+	if k.nullsFirst {
+		b.WriteString(" NULLS FIRST")
+	} else {
+		b.WriteString(" NULLS LAST")
+	}
+	return b.String()
+}
+
+// Paginate returns one page of the rows selected by the base query using keyset (seek) pagination:
+// instead of skipping rows with OFFSET, each page resumes strictly after the last row of the previous
+// page, so that the cost of fetching a page does not grow with its position.
+//
+// The rows are ordered by the given sort keys, which may mix ascending and descending directions and
+// place NULL values first or last. The keys should end with a unique column (typically the primary key)
+// so that the ordering is total; otherwise rows sharing the same key values may be skipped between pages.
+// Any ORDER BY, LIMIT or OFFSET of the base query is replaced, and the base query itself is not modified.
+//
+// Before running the query, the existence of the sort columns in the base table is checked with
+// ColsExists (ColsExistsIn when the table is schema-qualified or a default schema is configured).
+//
+// The returned cursor is an opaque token encoding the sort-key values of the last row of the page,
+// signed with HMAC-SHA256 using the cursor secret of the settings: a modified or forged cursor, or a
+// cursor issued for a different ordering, is rejected with a bad request. The sort columns must be
+// mapped by T (through `db` tags for structs, or as keys of a map[string]any).
+//
+// Parameters:
+//   - ctx:    The context controlling cancellation and deadlines.
+//   - exec:   The *Datasource or *Transaction to run the query on.
+//   - base:   The base query, providing the selected columns, table and filters.
+//   - keys:   The sort keys, from the most to the least significant.
+//   - size:   The maximum number of rows of the page.
+//   - cursor: The cursor returned with the previous page, or "" for the first page.
+//
+// Returns:
+//   - The page of rows, with the cursor of the next page when more rows follow.
+//   - A wrapify.R instance describing the outcome, with the number of rows of the page as total.
+//
+// Example:
+//
+//	base := pgc.Select("id", "email", "created_at").From("users").Where(pgc.Eq("active", true))
+//	keys := []pgc.SortKey{pgc.Desc("created_at").NullsLast(), pgc.Asc("id")}
+//	page, response := pgc.Paginate[User](ctx, ds, base, keys, 50, r.URL.Query().Get("cursor"))
+//	next := page.NextCursor // "" on the last page
+func Paginate[T any](ctx context.Context, exec Executor, base *SelectQuery, keys []SortKey, size int, cursor string) (page Page[T], response wrapify.R) {
+	ds, ext, response, ok := exec.executor()
+	if !ok {
+		return page, response
+	}
+	if base == nil || isEmpty(base.table) {
+		response := wrapify.WrapBadRequest("A base query selecting from a table is required", nil).BindCause()
+		ds.dispatchEvent(EventPaginate, EventLevelError, response.Reply())
+		return page, response.Reply()
+	}
+	if len(keys) == 0 {
+		response := wrapify.WrapBadRequest("At least one sort key is required", nil).BindCause()
+		ds.dispatchEvent(EventPaginate, EventLevelError, response.Reply())
+		return page, response.Reply()
+	}
+	if size <= 0 {
+		response := wrapify.WrapBadRequest(fmt.Sprintf("Page size must be positive, got %d", size), nil).BindCause()
+		ds.dispatchEvent(EventPaginate, EventLevelError, response.Reply())
+		return page, response.Reply()
+	}
+
+	columns := make([]string, len(keys))
+	order := make([]string, len(keys))
+	for i, key := range keys {
+		if isEmpty(key.column) {
+			response := wrapify.WrapBadRequest(fmt.Sprintf("Sort key %d has no column", i), nil).BindCause()
+			ds.dispatchEvent(EventPaginate, EventLevelError, response.Reply())
+			return page, response.Reply()
+		}
+		columns[i] = unqualify(key.column)
+		order[i] = key.String()
+	}
+	if response := ds.sortColsExist(ctx, base.table, columns); response.IsError() {
+		ds.dispatchEvent(EventPaginate, queryErrLevel(ctx, response.Cause()), response)
+		return page, response
+	}
+
+	// Work on a copy so that the caller's base query can be reused for other pages.
+	q := *base
+	q.where = slices.Clip(base.where)
+	q.orderBy = order
+	q.limit = size + 1 // One extra row tells whether a next page exists.
+	q.offset = -1
+	if isNotEmpty(cursor) {
+		values, err := decodeCursor(ds.cursorKey, strings.Join(order, ", "), cursor)
+		if err == nil && len(values) != len(keys) {
+			err = fmt.Errorf("pgc: cursor holds %d sort-key values, expected %d", len(values), len(keys))
+		}
+		if err != nil {
+			response := wrapify.WrapBadRequest("Invalid pagination cursor", nil).WithErrSck(err)
+			ds.dispatchEvent(EventPaginate, EventLevelError, response.Reply())
+			return page, response.Reply()
+		}
+		q.where = append(q.where, seekCond(keys, values))
+	}
+	query, args, err := q.Build()
+	if err != nil {
+		response := wrapify.WrapBadRequest(err.Error(), nil).WithErrSck(err)
+		ds.dispatchEvent(EventPaginate, EventLevelError, response.Reply())
+		return page, response.Reply()
+	}
+
+	var items []T
+	response = ds.selectContext(ctx, ext, "Paginate", &items, query, args)
+	if response.IsError() {
+		ds.dispatchEvent(EventPaginate, queryErrLevel(ctx, response.Cause()), response)
+		return page, response
+	}
+	if len(items) > size {
+		items = items[:size]
+		page.HasMore = true
+	}
+	page.Items = items
+
+	if page.HasMore {
+		values, err := copyValues(ds.Conn().Mapper, items[len(items)-1], columns)
+		if err == nil {
+			page.NextCursor, err = encodeCursor(ds.cursorKey, strings.Join(order, ", "), values)
+		}
+		if err != nil {
+			response := wrapify.WrapInternalServerError("An error occurred while encoding the next pagination cursor", nil).
+				WithDebuggingKV("sort_keys", order).
+				WithErrSck(err).
+				Reply()
+			ds.dispatchEvent(EventPaginate, EventLevelError, response)
+			return Page[T]{}, response
+		}
+	}
+
+	response = wrapify.WrapOk(fmt.Sprintf("Retrieved a page of %d rows from '%s' successfully", len(items), base.table), page).
+		WithDebuggingKV("sort_keys", order).
+		WithDebuggingKV("has_more", page.HasMore).
+		WithTotal(len(items)).
+		Reply()
+	ds.dispatchEvent(EventPaginate, EventLevelSuccess, response)
+	return page, response
+}
+
+// sortColsExist checks through ColsExists that every sort column exists in table,
+// reporting the missing ones as a bad request.
+func (d *Datasource) sortColsExist(ctx context.Context, table string, columns []string) wrapify.R {
+	var ces ColExistsSpecMeta
+	var response wrapify.R
+	if schema, name, ok := strings.Cut(table, "."); ok {
+		ces, response = d.ColsExistsInCtx(ctx, schema, []string{name}, columns)
+	} else if isNotEmpty(d.conf.schema) {
+		ces, response = d.ColsExistsInCtx(ctx, d.conf.schema, []string{table}, columns)
+	} else {
+		ces, response = d.ColsExistsCtx(ctx, []string{table}, columns)
+	}
+	if response.IsError() {
+		return response
+	}
+	if ces.Stats.TotalMissing > 0 {
+		missing := make([]string, 0, ces.Stats.TotalMissing)
+		for _, col := range ces.Stats.MissingCols {
+			missing = append(missing, col.ColumnName)
+		}
+		return wrapify.WrapBadRequest(fmt.Sprintf("Sort columns %v do not exist in table '%s'", missing, table), nil).
+			WithDebuggingKV("missing", missing).
+			BindCause().
+			Reply()
+	}
+	return response
+}
+
+// seekCond builds the condition selecting the rows that sort strictly after the row holding
+// the given sort-key values: for each key i, the rows whose first i-1 keys equal the values
+// and whose key i comes after its value, all alternatives being combined with OR.
+func seekCond(keys []SortKey, values []*string) Cond {
+	terms := make([]Cond, 0, len(keys))
+	for i, key := range keys {
+		after := key.after(values[i])
+		if after == nil {
+			continue
+		}
+		if i == 0 {
+			terms = append(terms, after)
+			continue
+		}
+		conds := make([]Cond, 0, i+1)
+		for j := range i {
+			conds = append(conds, keys[j].equal(values[j]))
+		}
+		terms = append(terms, And(append(conds, after)...))
+	}
+	return Or(terms...)
+}
+
+// equal returns the condition matching the rows whose key equals value (nil meaning NULL).
+func (k SortKey) equal(value *string) Cond {
+	if value == nil {
+		return IsNull(k.column)
+	}
+	return Eq(k.column, *value)
+}
+
+// after returns the condition matching the rows whose key sorts strictly after value
+// (nil meaning NULL), or nil when no value can sort after it.
+func (k SortKey) after(value *string) Cond {
+	if value == nil {
+		if k.nullsFirst {
+			return IsNotNull(k.column)
+		}
+		return nil
+	}
+	var cond Cond
+	if k.desc {
+		cond = Lt(k.column, *value)
+	} else {
+		cond = Gt(k.column, *value)
+	}
+	if !k.nullsFirst {
+		return Or(cond, IsNull(k.column))
+	}
+	return cond
+}
+
+// newCursorKey returns the HMAC key signing pagination cursors: the configured secret,
+// or a random key when none is configured.
+func newCursorKey(secret string) []byte {
+	if isNotEmpty(secret) {
+		return []byte(secret)
+	}
+	key := make([]byte, 32)
+	// crypto/rand only fails when the system's entropy source is unavailable.
+	_, _ = rand.Read(key)
+	return key
+}
+
+// encodeCursor converts the sort-key values to their text representation and returns
+// the signed cursor "<base64 payload>.<base64 signature>".
+func encodeCursor(key []byte, order string, values []any) (string, error) {
+	c := pageCursor{Order: order, Keys: make([]*string, len(values))}
+	for i, value := range values {
+		text, err := cursorText(value)
+		if err != nil {
+			return "", err
+		}
+		c.Keys[i] = text
+	}
+	payload, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString(payload) + "." + enc.EncodeToString(signCursor(key, payload)), nil
+}
+
+// decodeCursor verifies the signature of the cursor and that it was issued for the given
+// ordering, and returns the sort-key values it holds.
+func decodeCursor(key []byte, order string, cursor string) ([]*string, error) {
+	enc := base64.RawURLEncoding
+	encoded, signature, ok := strings.Cut(cursor, ".")
+	if !ok {
+		return nil, fmt.Errorf("pgc: malformed cursor")
+	}
+	payload, err := enc.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("pgc: malformed cursor: %w", err)
+	}
+	mac, err := enc.DecodeString(signature)
+	if err != nil {
+		return nil, fmt.Errorf("pgc: malformed cursor: %w", err)
+	}
+	if !hmac.Equal(mac, signCursor(key, payload)) {
+		return nil, fmt.Errorf("pgc: cursor signature mismatch")
+	}
+	var c pageCursor
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return nil, fmt.Errorf("pgc: malformed cursor: %w", err)
+	}
+	if c.Order != order {
+		return nil, fmt.Errorf("pgc: cursor was issued for ordering %q", c.Order)
+	}
+	return c.Keys, nil
+}
+
+// signCursor returns the HMAC-SHA256 of the cursor payload.
+func signCursor(key []byte, payload []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write(payload)
+	return h.Sum(nil)
+}
+
+// cursorText returns the text representation of a sort-key value, as sent to the server
+// by the driver, or nil for NULL. The value is bound back as an untyped parameter whose
+// type the server infers from the compared column.
+func cursorText(value any) (*string, error) {
+	v, err := driver.DefaultParameterConverter.ConvertValue(value)
+	if err != nil {
+		return nil, fmt.Errorf("pgc: unsupported sort-key value %T: %w", value, err)
+	}
+	var text string
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case int64:
+		text = strconv.FormatInt(v, 10)
+	case float64:
+		text = strconv.FormatFloat(v, 'g', -1, 64)
+	case bool:
+		text = strconv.FormatBool(v)
+	case []byte:
+		text = `\x` + hex.EncodeToString(v)
+	case string:
+		text = v
+	case time.Time:
+		text = v.Format(time.RFC3339Nano)
+	default:
+		return nil, fmt.Errorf("pgc: unsupported sort-key value %T", value)
+	}
+	return &text, nil
+}
+
+// unqualify strips the table qualifier of a column name ("u.id" becomes "id").
+func unqualify(column string) string {
+	return column[strings.LastIndexByte(column, '.')+1:]
+}
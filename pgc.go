@@ -2,6 +2,7 @@ package pgc
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -19,6 +20,80 @@ func NewSettings() *settings {
 	return &settings{}
 }
 
+// NewTxOptions initializes and returns a pointer to a new TxOptions instance.
+// The zero value uses the server defaults: the default isolation level, a read-write,
+// non-deferrable transaction, and no per-transaction timeouts.
+func NewTxOptions() *TxOptions {
+	return &TxOptions{}
+}
+
+// NewUpsertOptions initializes and returns a pointer to a new UpsertOptions instance.
+// The zero value resolves the conflict target from the table keys and updates every
+// non-key column on conflict.
+func NewUpsertOptions() *UpsertOptions {
+	return &UpsertOptions{}
+}
+
+// NewRetryPolicy initializes and returns a pointer to a new RetryPolicy instance with the default
+// settings: up to 3 attempts, a 100ms initial backoff doubling up to 5s, 50% jitter, and the
+// IsTransientErr classifier.
+func NewRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		maxAttempts: defaultRetryMaxAttempts,
+		backoff:     defaultRetryBackoff,
+		maxBackoff:  defaultRetryMaxBackoff,
+		jitter:      defaultRetryJitter,
+	}
+}
+
+// NewBreakerPolicy initializes and returns a pointer to a new BreakerPolicy instance with the default
+// settings: the circuit breaker opens after 5 consecutive failures or a 50% failure rate over 20 or more
+// operations within 30s, stays open for 10s and then lets a single probe operation through.
+func NewBreakerPolicy() *BreakerPolicy {
+	return &BreakerPolicy{
+		failureThreshold: defaultBreakerFailureThreshold,
+		errorRate:        defaultBreakerErrorRate,
+		minRequests:      defaultBreakerMinRequests,
+		window:           defaultBreakerWindow,
+		openTimeout:      defaultBreakerOpenTimeout,
+		halfOpenProbes:   defaultBreakerHalfOpenProbes,
+	}
+}
+
+// NewCDCOptions initializes and returns a pointer to a new CDCOptions instance.
+// The zero value reads a test_decoding slot, peeks defaultCDCBatchSize changes per poll,
+// waits defaultCDCPollInterval when no change is pending and reports the slot lag as debug events.
+func NewCDCOptions() *CDCOptions {
+	return &CDCOptions{}
+}
+
+// NewOutboxOptions initializes and returns a pointer to a new OutboxOptions instance.
+// The zero value claims 100 messages per dispatch, polls every second, publishes 4 messages
+// concurrently and dead-letters a message after 5 attempts, retried after 1s, 2s, 4s, ... up to 5m.
+func NewOutboxOptions() *OutboxOptions {
+	return &OutboxOptions{}
+}
+
+// NewJobOptions initializes and returns a pointer to a new JobOptions instance.
+// The zero value runs the job now, with priority 0, up to defaultJobMaxAttempts times and without uniqueness key.
+func NewJobOptions() *JobOptions {
+	return &JobOptions{}
+}
+
+// NewJobWorkerOptions initializes and returns a pointer to a new JobWorkerOptions instance.
+// The zero value runs 4 jobs concurrently, polls every second, sends heartbeats every 10s, reclaims
+// the jobs without heartbeat for a minute, and retries failed jobs after 1s, 2s, 4s, ... up to an hour.
+func NewJobWorkerOptions() *JobWorkerOptions {
+	return &JobWorkerOptions{}
+}
+
+// NewClusterOptions initializes and returns a pointer to a new ClusterOptions instance.
+// The zero value routes reads round-robin, probes the replicas every defaultReplicaCheckInterval
+// and does not check replication lag.
+func NewClusterOptions() *ClusterOptions {
+	return &ClusterOptions{}
+}
+
 // NewClient creates and returns a fully configured Datasource instance for PostgreSQL based on
 // the provided Settings configuration. This function attempts to establish an initial connection,
 // validate connectivity via a ping, and configure connection pool parameters (max idle, max open,
@@ -29,7 +104,10 @@ func NewSettings() *settings {
 //   - A pointer to a Datasource instance that encapsulates the PostgreSQL connection and its configuration.
 func NewClient(conf settings) *Datasource {
 	datasource := &Datasource{
-		conf: conf,
+		conf:      conf,
+		stmtCache: newStmtCache(conf.StmtCacheSize()),
+		cursorKey: newCursorKey(conf.CursorSecret()),
+		breaker:   newCircuitBreaker(conf.BreakerPolicy()),
 	}
 	start := time.Now()
 	if !conf.IsEnabled() {
@@ -75,30 +153,47 @@ func NewClient(conf settings) *Datasource {
 	c.SetMaxOpenConns(conf.MaxOpenConn())
 	c.SetConnMaxLifetime(conf.ConnMaxLifetime())
 
-	// Set the established connection and update the wrap response to indicate success.
+	// Set the established connection and mark the datasource as ready.
 	datasource.SetConn(c)
-	datasource.SetState(wrapify.New().
+	datasource.setup()
+	datasource.activate(start)
+	return datasource
+}
+
+// setup registers the default chain callbacks for connection lifecycle and query observability
+// that are not set yet, and initializes the worker pools for async dispatching.
+func (d *Datasource) setup() {
+	d.mu.RLock()
+	reconnect, inspector, event := d.on_reconnect == nil, d.inspector == nil, d.on_event == nil
+	d.mu.RUnlock()
+	if reconnect {
+		d.OnReconnect(DefaultReconnectChain())
+	}
+	if inspector {
+		d.OnInspector(DefaultInspectorChain())
+	}
+	if event {
+		d.OnEvent(DefaultEventCallbackChain())
+	}
+	if d.eventPool == nil {
+		d.initPools()
+	}
+}
+
+// activate updates the state of a freshly connected datasource to indicate success and,
+// if keepalive is enabled, initiates the background routine to monitor connection health.
+func (d *Datasource) activate(start time.Time) {
+	d.SetState(wrapify.New().
 		WithStatusCode(http.StatusOK).
-		WithDebuggingKV("pgsql_conn_str", conf.String(true)).
+		WithDebuggingKV("pgsql_conn_str", d.conf.String(true)).
 		WithDebuggingKV("executed_in", time.Since(start).String()).
-		WithMessagef("Successfully connected to the postgresql database: '%s'", conf.ConnString()).
+		WithMessagef("Successfully connected to the postgresql database: '%s'", d.conf.ConnString()).
 		WithHeader(wrapify.OK).
 		Reply())
 
-	// Register default chain callbacks for connection lifecycle and query observability.
-	datasource.OnReconnect(DefaultReconnectChain())
-	datasource.OnInspector(DefaultInspectorChain())
-	datasource.OnEvent(DefaultEventCallbackChain())
-
-	// Initialize worker pools for async dispatching
-	datasource.initPools()
-
-	// If keepalive is enabled, initiate the background routine to monitor connection health.
-	if conf.keepalive {
-		datasource.keepalive()
+	if d.conf.keepalive {
+		d.keepalive()
 	}
-
-	return datasource
 }
 
 // BeginTx starts a new database transaction within the context of the Datasource.
@@ -106,6 +201,45 @@ func NewClient(conf settings) *Datasource {
 // error response. Otherwise, it attempts to begin a transaction using the underlying
 // sqlx connection and returns a Transaction instance representing the active transaction.
 func (d *Datasource) BeginTx(ctx context.Context) *Transaction {
+	t, _ := d.beginTx(ctx, nil)
+	return t
+}
+
+// BeginTxWith starts a new database transaction configured by the provided TxOptions.
+//
+// The isolation level and read-only mode are passed to the driver when the transaction begins.
+// DEFERRABLE is applied through SET TRANSACTION, and the statement_timeout, lock_timeout and
+// idle_in_transaction_session_timeout values are issued as SET LOCAL statements, so they only
+// apply to this transaction and are reset automatically when it ends. If any of these settings
+// cannot be applied, the transaction is rolled back and an inactive Transaction is returned.
+//
+// The chosen options are recorded as debugging key-values on the EventTxStarted response.
+//
+// Parameters:
+//   - ctx:  The context used to begin the transaction and apply its settings.
+//   - opts: The transaction options; nil behaves like BeginTx.
+//
+// Returns:
+//   - A pointer to a Transaction; check IsActivated() or Wrap() for the outcome.
+//
+// Example:
+//
+//	opts := pgc.NewTxOptions().
+//	    SetIsolation(sql.LevelSerializable).
+//	    SetReadOnly(true).
+//	    SetDeferrable(true).
+//	    SetStatementTimeout(30 * time.Second)
+//	tx := ds.BeginTxWith(ctx, opts)
+func (d *Datasource) BeginTxWith(ctx context.Context, opts *TxOptions) *Transaction {
+	t, _ := d.beginTx(ctx, opts)
+	return t
+}
+
+// beginTx starts a new database transaction using the provided options and returns the
+// resulting Transaction together with the error that prevented it from starting, if any.
+// The returned Transaction is never nil; when the transaction could not be started it is
+// inactive and its Wrap() response describes the failure.
+func (d *Datasource) beginTx(ctx context.Context, opts *TxOptions) (*Transaction, error) {
 	if !d.IsConnected() {
 		response := wrapify.WrapServiceUnavailable("Datasource is not connected", nil).BindCause().WithHeader(wrapify.ServiceUnavailable).Reply()
 		d.dispatchEvent(EventConnClose, EventLevelError, response)
@@ -115,14 +249,18 @@ func (d *Datasource) BeginTx(ctx context.Context) *Transaction {
 			active: false,
 			wrap:   response,
 		}
-		return t
+		return t, errDatasourceNotConnected
 	}
 
 	d.dispatchEvent(EventTxBegin, EventLevelInfo, wrapify.WrapProcessing("Starting transaction", nil).WithHeader(wrapify.Processing).Reply())
 
-	tx, err := d.Conn().BeginTxx(ctx, nil)
-	if err != nil {
-		response := wrapify.WrapInternalServerError("Failed to start transaction", nil).WithHeader(wrapify.InternalServerError).WithErrSck(err).Reply()
+	var tx *sqlx.Tx
+	err := d.guard(ctx, func() (err error) {
+		tx, err = d.Conn().BeginTxx(ctx, opts.sqlOptions())
+		return err
+	})
+	if errors.Is(err, ErrCircuitOpen) {
+		response := wrapify.WrapServiceUnavailable("Failed to start transaction, the circuit breaker is open", nil).WithHeader(wrapify.ServiceUnavailable).WithErrSck(err).Reply()
 		d.dispatchEvent(EventTxStartedAbort, EventLevelError, response)
 		t := &Transaction{
 			ds:     d,
@@ -130,25 +268,70 @@ func (d *Datasource) BeginTx(ctx context.Context) *Transaction {
 			active: false,
 			wrap:   response,
 		}
-		return t
+		return t, err
+	}
+	if err == nil {
+		err = d.applyTxOptions(ctx, tx, opts)
+	}
+	if err != nil {
+		response := wrapQueryErr(ctx, err, "Failed to start transaction", nil)
+		d.dispatchEvent(EventTxStartedAbort, queryErrLevel(ctx, err), response)
+		t := &Transaction{
+			ds:     d,
+			tx:     nil,
+			active: false,
+			wrap:   response,
+		}
+		return t, err
 	}
-	response := wrapify.WrapOk("Transaction started successfully", nil).WithHeader(wrapify.OK).Reply()
+	response := wrapify.WrapOk("Transaction started successfully", nil).
+		WithDebuggingKV("isolation_level", opts.IsolationLevel().String()).
+		WithDebuggingKV("read_only", opts.IsReadOnly()).
+		WithDebuggingKV("deferrable", opts.IsDeferrable()).
+		WithDebuggingKV("statement_timeout", opts.StatementTimeout().String()).
+		WithDebuggingKV("lock_timeout", opts.LockTimeout().String()).
+		WithDebuggingKV("idle_in_transaction_session_timeout", opts.IdleInTxSessionTimeout().String()).
+		WithHeader(wrapify.OK).
+		Reply()
 	t := &Transaction{
 		ds:     d,
 		tx:     tx,
 		active: true,
 		wrap:   response,
+		ctx:    ctx,
 	}
 	d.dispatchEvent(EventTxStarted, EventLevelSuccess, response)
-	return t
+	return t, nil
+}
+
+// applyTxOptions issues the statements required by the options that cannot be expressed through
+// sql.TxOptions (DEFERRABLE and the SET LOCAL timeouts) on a freshly started transaction.
+// If any statement fails, the transaction is rolled back and the error is returned.
+func (d *Datasource) applyTxOptions(ctx context.Context, tx *sqlx.Tx, opts *TxOptions) error {
+	for _, query := range opts.statements() {
+		// Start inspection
+		done := d.Inspect("BeginTxWith", query)
+		_, err := tx.ExecContext(ctx, query)
+		// End inspection
+		done()
+
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return nil
 }
 
 // Close releases all resources associated with the Datasource,
-// including stopping worker pools and closing the database connection.
+// including stopping worker pools, closing the notification listener and closing the database connection.
 //
 // Returns:
-//   - An error if closing the connection fails.
+//   - An error if closing the listener or the connection fails.
 func (d *Datasource) Close() error {
+	// Close the notification listener, if any
+	listenErr := d.closeListener()
+
 	// Stop worker pools gracefully
 	if d.eventPool != nil {
 		if !d.eventPool.Stop() {
@@ -161,15 +344,18 @@ func (d *Datasource) Close() error {
 		}
 	}
 
+	// Release cached prepared statements before the connection goes away
+	d.stmtCache.purge()
+
 	// Close database connection
 	d.mu.Lock()
 	conn := d.conn
 	d.mu.Unlock()
 
 	if conn != nil {
-		return conn.Close()
+		return errors.Join(listenErr, conn.Close())
 	}
-	return nil
+	return listenErr
 }
 
 // Inspect is a helper method to Inspect a query with timing.
@@ -274,6 +460,9 @@ func (d *Datasource) keepalive() {
 					Reply()
 			}
 			d.SetState(response)
+			if response.IsSuccess() {
+				d.closeBreaker("keepalive ping succeeded")
+			}
 			d.dispatchReconnect(response, d)
 		}
 	}()
@@ -324,6 +513,8 @@ func (d *Datasource) reconnect() error {
 	previous := d.conn
 	d.conn = current
 	d.mu.Unlock()
+	// Cached statements are bound to the previous connection and cannot be reused.
+	d.stmtCache.purge()
 	if previous != nil {
 		previous.Close()
 	}
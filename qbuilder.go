@@ -0,0 +1,562 @@
+package pgc
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sivaosorg/wrapify"
+)
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Query Builder Conditions
+// Identifiers are quoted following PostgreSQL's quote_ident rules and every
+// value is bound as a numbered $n placeholder, never interpolated.
+//_______________________________________________________________________
+
+// Eq returns the condition "column = value".
+func Eq(column string, value any) Cond { return cmpCond{column, "=", value} }
+
+// Ne returns the condition "column <> value".
+func Ne(column string, value any) Cond { return cmpCond{column, "<>", value} }
+
+// Gt returns the condition "column > value".
+func Gt(column string, value any) Cond { return cmpCond{column, ">", value} }
+
+// Gte returns the condition "column >= value".
+func Gte(column string, value any) Cond { return cmpCond{column, ">=", value} }
+
+// Lt returns the condition "column < value".
+func Lt(column string, value any) Cond { return cmpCond{column, "<", value} }
+
+// Lte returns the condition "column <= value".
+func Lte(column string, value any) Cond { return cmpCond{column, "<=", value} }
+
+// Like returns the condition "column LIKE pattern".
+func Like(column string, pattern string) Cond { return cmpCond{column, "LIKE", pattern} }
+
+// ILike returns the condition "column ILIKE pattern" (case-insensitive LIKE).
+func ILike(column string, pattern string) Cond { return cmpCond{column, "ILIKE", pattern} }
+
+// IsNull returns the condition "column IS NULL".
+func IsNull(column string) Cond { return nullCond{column: column} }
+
+// IsNotNull returns the condition "column IS NOT NULL".
+func IsNotNull(column string) Cond { return nullCond{column: column, not: true} }
+
+// In returns the condition "column = ANY($n)", binding values (a slice) as a single array
+// parameter through pq.Array. An empty slice matches no rows.
+//
+// Example:
+//
+//	pgc.In("status", []string{"active", "pending"}) // status = ANY($1)
+func In(column string, values any) Cond { return inCond{column: column, values: values} }
+
+// NotIn returns the condition "column <> ALL($n)", binding values (a slice) as a single array
+// parameter through pq.Array. An empty slice matches every row.
+func NotIn(column string, values any) Cond { return inCond{column: column, values: values, not: true} }
+
+// And combines conditions with AND. Nil conditions are ignored.
+func And(conds ...Cond) Cond { return groupCond{"AND", conds} }
+
+// Or combines conditions with OR. Nil conditions are ignored.
+func Or(conds ...Cond) Cond { return groupCond{"OR", conds} }
+
+// Not negates a condition.
+func Not(cond Cond) Cond { return notCond{cond} }
+
+// Raw returns a raw SQL condition. Each ? in sql is replaced by the next numbered placeholder
+// and bound to the corresponding argument; identifiers in sql are used verbatim, so Raw must
+// never be built from untrusted input.
+//
+// A ? within a quoted literal or identifier ('...' or "...") is kept as is, as are the jsonb ?| and
+// ?& operators. The jsonb ? operator must be escaped as ?? to be told apart from a placeholder.
+// Building a statement fails when the number of placeholders differs from the number of arguments.
+//
+// Example:
+//
+//	pgc.Raw("lower(email) = lower(?)", email)
+//	pgc.Raw("data ?? 'admin' AND data->>'team' = ?", team) // data ? 'admin' AND data->>'team' = $1
+func Raw(sql string, args ...any) Cond { return rawCond{sql, args} }
+
+// build returns the statement text and its arguments, or the error recorded while rendering it.
+func (b *sqlBuf) build() (string, []any, error) {
+	if b.err != nil {
+		return "", nil, b.err
+	}
+	return b.String(), b.args, nil
+}
+
+// bind appends value to the arguments and writes its $n placeholder.
+func (b *sqlBuf) bind(value any) {
+	b.args = append(b.args, value)
+	b.WriteByte('$')
+	b.WriteString(strconv.Itoa(len(b.args)))
+}
+
+// ident writes the quoted identifier. "*" and "table.*" are written as-is.
+func (b *sqlBuf) ident(name string) {
+	if name == "*" {
+		b.WriteString(name)
+		return
+	}
+	if prefix, ok := strings.CutSuffix(name, ".*"); ok {
+		b.WriteString(quoteIdent(prefix))
+		b.WriteString(".*")
+		return
+	}
+	b.WriteString(quoteIdent(name))
+}
+
+// idents writes the quoted identifiers separated by ", ".
+func (b *sqlBuf) idents(names []string) {
+	for i, name := range names {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.ident(name)
+	}
+}
+
+// where writes the WHERE clause ANDing the given conditions, if any.
+func (b *sqlBuf) where(conds []Cond) {
+	if c := compact(conds); len(c) > 0 {
+		b.WriteString(" WHERE ")
+		groupCond{"AND", c}.renderBare(b)
+	}
+}
+
+// returning writes the RETURNING clause, if any.
+func (b *sqlBuf) returning(columns []string) {
+	if len(columns) > 0 {
+		b.WriteString(" RETURNING ")
+		b.idents(columns)
+	}
+}
+
+func (c cmpCond) render(b *sqlBuf) {
+	b.ident(c.column)
+	b.WriteByte(' ')
+	b.WriteString(c.op)
+	b.WriteByte(' ')
+	b.bind(c.value)
+}
+
+func (c nullCond) render(b *sqlBuf) {
+	b.ident(c.column)
+	if c.not {
+		b.WriteString(" IS NOT NULL")
+	} else {
+		b.WriteString(" IS NULL")
+	}
+}
+
+func (c inCond) render(b *sqlBuf) {
+	b.ident(c.column)
+	if c.not {
+		b.WriteString(" <> ALL(")
+	} else {
+		b.WriteString(" = ANY(")
+	}
+	b.bind(pq.Array(c.values))
+	b.WriteByte(')')
+}
+
+func (c groupCond) render(b *sqlBuf) {
+	conds := compact(c.conds)
+	if len(conds) == 0 {
+		// An empty AND is always true, an empty OR always false.
+		b.WriteString(strconv.FormatBool(c.op == "AND"))
+		return
+	}
+	b.WriteByte('(')
+	groupCond{c.op, conds}.renderBare(b)
+	b.WriteByte(')')
+}
+
+// renderBare writes the joined conditions without the surrounding parentheses.
+func (c groupCond) renderBare(b *sqlBuf) {
+	for i, cond := range c.conds {
+		if i > 0 {
+			b.WriteByte(' ')
+			b.WriteString(c.op)
+			b.WriteByte(' ')
+		}
+		cond.render(b)
+	}
+}
+
+func (c notCond) render(b *sqlBuf) {
+	b.WriteString("NOT (")
+	if c.cond == nil {
+		b.WriteString("true")
+	} else {
+		c.cond.render(b)
+	}
+	b.WriteByte(')')
+}
+
+func (c rawCond) render(b *sqlBuf) {
+	b.WriteByte('(')
+	sql := []rune(c.sql)
+	placeholders := 0
+	var quote rune
+	for i := 0; i < len(sql); i++ {
+		r := sql[i]
+		switch {
+		case quote != 0:
+			// A doubled quote closes and reopens the literal, which leaves it unchanged.
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '?' && i+1 < len(sql) && sql[i+1] == '?':
+			// Escaped jsonb ? operator.
+			i++
+		case r == '?' && i+1 < len(sql) && (sql[i+1] == '|' || sql[i+1] == '&'):
+			// jsonb ?| and ?& operators.
+			b.WriteRune(r)
+			i++
+			r = sql[i]
+		case r == '?':
+			if placeholders < len(c.args) {
+				b.bind(c.args[placeholders])
+			}
+			placeholders++
+			continue
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte(')')
+	if placeholders != len(c.args) && b.err == nil {
+		b.err = fmt.Errorf("pgc: raw condition %q has %d placeholders, expected %d", c.sql, placeholders, len(c.args))
+	}
+}
+
+// compact returns the non-nil conditions.
+func compact(conds []Cond) []Cond {
+	out := make([]Cond, 0, len(conds))
+	for _, cond := range conds {
+		if cond != nil {
+			out = append(out, cond)
+		}
+	}
+	return out
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Query Builder Statements
+//_______________________________________________________________________
+
+// Select starts building a SELECT statement returning the given columns ("*" when none are given).
+//
+// Example:
+//
+//	query, args, err := pgc.Select("id", "email").
+//	    From("users").
+//	    Where(pgc.Eq("active", true), pgc.Or(pgc.ILike("email", "%@acme.io"), pgc.In("role", roles))).
+//	    OrderByDesc("created_at").
+//	    Limit(50).
+//	    Build()
+//	// SELECT id, email FROM users WHERE active = $1 AND (email ILIKE $2 OR role = ANY($3))
+//	// ORDER BY created_at DESC LIMIT 50
+func Select(columns ...string) *SelectQuery {
+	q := &SelectQuery{columns: columns, limit: -1, offset: -1}
+	q.queryRunner = queryRunner{funcName: "QueryBuilder.Select", build: q.Build}
+	return q
+}
+
+// From sets the table the rows are selected from and returns the updated SelectQuery.
+func (q *SelectQuery) From(table string) *SelectQuery {
+	q.table = table
+	return q
+}
+
+// Where adds conditions to the WHERE clause; all conditions, across calls, are combined with AND.
+func (q *SelectQuery) Where(conds ...Cond) *SelectQuery {
+	q.where = append(q.where, conds...)
+	return q
+}
+
+// OrderBy appends ascending sort columns and returns the updated SelectQuery.
+func (q *SelectQuery) OrderBy(columns ...string) *SelectQuery {
+	for _, column := range columns {
+		q.orderBy = append(q.orderBy, quoteIdent(column)+" ASC")
+	}
+	return q
+}
+
+// OrderByDesc appends descending sort columns and returns the updated SelectQuery.
+func (q *SelectQuery) OrderByDesc(columns ...string) *SelectQuery {
+	for _, column := range columns {
+		q.orderBy = append(q.orderBy, quoteIdent(column)+" DESC")
+	}
+	return q
+}
+
+// Limit sets the maximum number of returned rows and returns the updated SelectQuery.
+func (q *SelectQuery) Limit(n int) *SelectQuery {
+	q.limit = n
+	return q
+}
+
+// Offset sets the number of skipped rows and returns the updated SelectQuery.
+func (q *SelectQuery) Offset(n int) *SelectQuery {
+	q.offset = n
+	return q
+}
+
+// Build renders the SELECT statement and its arguments.
+func (q *SelectQuery) Build() (string, []any, error) {
+	if isEmpty(q.table) {
+		return "", nil, fmt.Errorf("pgc: select requires a table")
+	}
+	var b sqlBuf
+	b.WriteString("SELECT ")
+	if len(q.columns) == 0 {
+		b.WriteString("*")
+	} else {
+		b.idents(q.columns)
+	}
+	b.WriteString(" FROM ")
+	b.ident(q.table)
+	b.where(q.where)
+	if len(q.orderBy) > 0 {
+		b.WriteString(" ORDER BY ")
+		b.WriteString(strings.Join(q.orderBy, ", "))
+	}
+	if q.limit >= 0 {
+		b.WriteString(" LIMIT ")
+		b.WriteString(strconv.Itoa(q.limit))
+	}
+	if q.offset >= 0 {
+		b.WriteString(" OFFSET ")
+		b.WriteString(strconv.Itoa(q.offset))
+	}
+	return b.build()
+}
+
+// InsertInto starts building an INSERT statement into the given table.
+//
+// Example:
+//
+//	query, args, err := pgc.InsertInto("users").
+//	    Columns("email", "name").
+//	    Values("a@acme.io", "Alice").
+//	    Values("b@acme.io", "Bob").
+//	    Returning("id").
+//	    Build()
+//	// INSERT INTO users (email, name) VALUES ($1, $2), ($3, $4) RETURNING id
+func InsertInto(table string) *InsertQuery {
+	q := &InsertQuery{table: table}
+	q.queryRunner = queryRunner{funcName: "QueryBuilder.Insert", build: q.Build}
+	return q
+}
+
+// Columns sets the inserted columns and returns the updated InsertQuery.
+func (q *InsertQuery) Columns(columns ...string) *InsertQuery {
+	q.columns = columns
+	return q
+}
+
+// Values appends a row of values, in column order, and returns the updated InsertQuery.
+func (q *InsertQuery) Values(values ...any) *InsertQuery {
+	q.rows = append(q.rows, values)
+	return q
+}
+
+// Returning sets the columns of the RETURNING clause and returns the updated InsertQuery.
+func (q *InsertQuery) Returning(columns ...string) *InsertQuery {
+	q.returning = columns
+	return q
+}
+
+// Build renders the INSERT statement and its arguments.
+func (q *InsertQuery) Build() (string, []any, error) {
+	if isEmpty(q.table) {
+		return "", nil, fmt.Errorf("pgc: insert requires a table")
+	}
+	if len(q.columns) == 0 || len(q.rows) == 0 {
+		return "", nil, fmt.Errorf("pgc: insert requires columns and at least one row of values")
+	}
+	var b sqlBuf
+	b.WriteString("INSERT INTO ")
+	b.ident(q.table)
+	b.WriteString(" (")
+	b.idents(q.columns)
+	b.WriteString(") VALUES ")
+	for i, row := range q.rows {
+		if len(row) != len(q.columns) {
+			return "", nil, fmt.Errorf("pgc: insert row %d has %d values, expected %d", i, len(row), len(q.columns))
+		}
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteByte('(')
+		for j, value := range row {
+			if j > 0 {
+				b.WriteString(", ")
+			}
+			b.bind(value)
+		}
+		b.WriteByte(')')
+	}
+	b.returning(q.returning)
+	return b.String(), b.args, nil
+}
+
+// Update starts building an UPDATE statement on the given table.
+//
+// Example:
+//
+//	query, args, err := pgc.Update("users").
+//	    Set("name", "Alice").
+//	    Where(pgc.Eq("id", 42)).
+//	    Build()
+//	// UPDATE users SET name = $1 WHERE id = $2
+func Update(table string) *UpdateQuery {
+	q := &UpdateQuery{table: table}
+	q.queryRunner = queryRunner{funcName: "QueryBuilder.Update", build: q.Build}
+	return q
+}
+
+// Set assigns a value to a column and returns the updated UpdateQuery.
+func (q *UpdateQuery) Set(column string, value any) *UpdateQuery {
+	q.sets = append(q.sets, column)
+	q.values = append(q.values, value)
+	return q
+}
+
+// Where adds conditions to the WHERE clause; all conditions, across calls, are combined with AND.
+func (q *UpdateQuery) Where(conds ...Cond) *UpdateQuery {
+	q.where = append(q.where, conds...)
+	return q
+}
+
+// Returning sets the columns of the RETURNING clause and returns the updated UpdateQuery.
+func (q *UpdateQuery) Returning(columns ...string) *UpdateQuery {
+	q.returning = columns
+	return q
+}
+
+// Build renders the UPDATE statement and its arguments.
+func (q *UpdateQuery) Build() (string, []any, error) {
+	if isEmpty(q.table) {
+		return "", nil, fmt.Errorf("pgc: update requires a table")
+	}
+	if len(q.sets) == 0 {
+		return "", nil, fmt.Errorf("pgc: update requires at least one column to set")
+	}
+	var b sqlBuf
+	b.WriteString("UPDATE ")
+	b.ident(q.table)
+	b.WriteString(" SET ")
+	for i, column := range q.sets {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.ident(column)
+		b.WriteString(" = ")
+		b.bind(q.values[i])
+	}
+	b.where(q.where)
+	b.returning(q.returning)
+	return b.build()
+}
+
+// DeleteFrom starts building a DELETE statement on the given table.
+// Without any Where condition, the statement deletes every row of the table.
+//
+// Example:
+//
+//	query, args, err := pgc.DeleteFrom("sessions").Where(pgc.Lt("expires_at", time.Now())).Build()
+//	// DELETE FROM sessions WHERE expires_at < $1
+func DeleteFrom(table string) *DeleteQuery {
+	q := &DeleteQuery{table: table}
+	q.queryRunner = queryRunner{funcName: "QueryBuilder.Delete", build: q.Build}
+	return q
+}
+
+// Where adds conditions to the WHERE clause; all conditions, across calls, are combined with AND.
+func (q *DeleteQuery) Where(conds ...Cond) *DeleteQuery {
+	q.where = append(q.where, conds...)
+	return q
+}
+
+// Returning sets the columns of the RETURNING clause and returns the updated DeleteQuery.
+func (q *DeleteQuery) Returning(columns ...string) *DeleteQuery {
+	q.returning = columns
+	return q
+}
+
+// Build renders the DELETE statement and its arguments.
+func (q *DeleteQuery) Build() (string, []any, error) {
+	if isEmpty(q.table) {
+		return "", nil, fmt.Errorf("pgc: delete requires a table")
+	}
+	var b sqlBuf
+	b.WriteString("DELETE FROM ")
+	b.ident(q.table)
+	b.where(q.where)
+	b.returning(q.returning)
+	return b.build()
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Query Builder Execution
+//_______________________________________________________________________
+
+// Exec builds the statement and executes it on the given Executor (a *Datasource or a *Transaction)
+// through the inspected execution path, so that the final SQL reaches the QueryInspector.
+//
+// Returns:
+//   - The sql.Result reported by the driver (nil on failure).
+//   - A wrapify.R instance describing the outcome; a bad request when the statement cannot be built.
+func (r queryRunner) Exec(ctx context.Context, exec Executor) (sql.Result, wrapify.R) {
+	ds, ext, query, args, response, ok := r.prepare(exec)
+	if !ok {
+		return nil, response
+	}
+	return ds.execContext(ctx, ext, r.funcName, query, args)
+}
+
+// Select builds the statement, executes it on the given Executor and scans all resulting rows
+// into dest, which must be a pointer to a slice.
+func (r queryRunner) Select(ctx context.Context, exec Executor, dest any) wrapify.R {
+	ds, ext, query, args, response, ok := r.prepare(exec)
+	if !ok {
+		return response
+	}
+	return ds.selectContext(ctx, ext, r.funcName, dest, query, args)
+}
+
+// Get builds the statement, executes it on the given Executor and scans the first resulting row
+// into dest. A not found response is returned when the statement returns no rows.
+func (r queryRunner) Get(ctx context.Context, exec Executor, dest any) wrapify.R {
+	ds, ext, query, args, response, ok := r.prepare(exec)
+	if !ok {
+		return response
+	}
+	return ds.getContext(ctx, ext, r.funcName, dest, query, args)
+}
+
+// prepare resolves the Executor and builds the statement, reporting a bad request response
+// when the statement is invalid.
+func (r queryRunner) prepare(exec Executor) (ds *Datasource, ext sqlx.ExtContext, query string, args []any, response wrapify.R, ok bool) {
+	ds, ext, response, ok = exec.executor()
+	if !ok {
+		return
+	}
+	query, args, err := r.build()
+	if err != nil {
+		response = wrapify.WrapBadRequest(err.Error(), nil).WithErrSck(err).Reply()
+		ds.dispatchEvent(EventQueryBuild, EventLevelError, response)
+		return ds, ext, "", nil, response, false
+	}
+	return ds, ext, query, args, response, true
+}
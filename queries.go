@@ -0,0 +1,363 @@
+package pgc
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/sivaosorg/wrapify"
+)
+
+// queryNameMarker matches the "-- name: <Name>" line introducing a query. Trailing annotations
+// (e.g. "-- name: GetUserByID :one", as written for sqlc) are ignored.
+var queryNameMarker = regexp.MustCompile(`^\s*--\s*name:\s*(\S+)`)
+
+// NewQueryRegistry loads the named SQL queries of the files of fsys matching the given glob
+// patterns (every .sql file of fsys, recursively, when no pattern is given).
+//
+// Each query starts at a "-- name: <Name>" marker line and extends up to the next marker or the end
+// of the file; content preceding the first marker of a file is ignored. Loading fails fast when no
+// file matches, when a query is empty, or when a name is defined more than once, across all files.
+//
+// Parameters:
+//   - fsys:     The file system holding the queries, e.g. an embed.FS or os.DirFS("sql").
+//   - patterns: Optional fs.Glob patterns selecting the files (e.g. "queries/*.sql").
+//
+// Returns:
+//   - The loaded registry.
+//   - An error describing the first invalid or duplicate query.
+//
+// Example:
+//
+//	//go:embed sql/*.sql
+//	var sqlFiles embed.FS
+//
+//	queries, err := pgc.NewQueryRegistry(sqlFiles, "sql/*.sql")
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	ds.SetQueries(queries)
+func NewQueryRegistry(fsys fs.FS, patterns ...string) (*QueryRegistry, error) {
+	r := &QueryRegistry{fsys: fsys, patterns: patterns}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Query returns the SQL of the named query.
+func (r *QueryRegistry) Query(name string) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	q, ok := r.queries[name]
+	return q.sql, ok
+}
+
+// Names returns the names of the registered queries, sorted alphabetically.
+func (r *QueryRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Sorted(maps.Keys(r.queries))
+}
+
+// Len returns the number of registered queries.
+func (r *QueryRegistry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.queries)
+}
+
+// Reload reads the files again and replaces the registered queries.
+// When the files are invalid, the previously loaded queries are kept and the error is returned.
+func (r *QueryRegistry) Reload() error {
+	files, err := r.files()
+	if err != nil {
+		return err
+	}
+	queries := make(map[string]namedQuery)
+	stamps := make(map[string]fileStamp, len(files))
+	for _, file := range files {
+		info, err := fs.Stat(r.fsys, file)
+		if err != nil {
+			return fmt.Errorf("pgc: %w", err)
+		}
+		content, err := fs.ReadFile(r.fsys, file)
+		if err != nil {
+			return fmt.Errorf("pgc: %w", err)
+		}
+		if err := parseQueries(file, string(content), queries); err != nil {
+			return err
+		}
+		stamps[file] = fileStamp{modTime: info.ModTime(), size: info.Size()}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queries = queries
+	r.stamps = stamps
+	return nil
+}
+
+// changed reports whether a file was added, removed or modified since the last load.
+// Files of an embed.FS never change, since they report no modification time.
+func (r *QueryRegistry) changed() (bool, error) {
+	files, err := r.files()
+	if err != nil {
+		return false, err
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(files) != len(r.stamps) {
+		return true, nil
+	}
+	for _, file := range files {
+		info, err := fs.Stat(r.fsys, file)
+		if err != nil {
+			return false, fmt.Errorf("pgc: %w", err)
+		}
+		stamp, ok := r.stamps[file]
+		if !ok || !stamp.modTime.Equal(info.ModTime()) || stamp.size != info.Size() {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// files returns the sorted paths of the files matching the patterns of the registry.
+func (r *QueryRegistry) files() ([]string, error) {
+	var files []string
+	if len(r.patterns) == 0 {
+		err := fs.WalkDir(r.fsys, ".", func(p string, e fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !e.IsDir() && path.Ext(p) == ".sql" {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("pgc: %w", err)
+		}
+	}
+	for _, pattern := range r.patterns {
+		matches, err := fs.Glob(r.fsys, pattern)
+		if err != nil {
+			return nil, fmt.Errorf("pgc: invalid query file pattern %q: %w", pattern, err)
+		}
+		files = append(files, matches...)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("pgc: no query files match %v", r.patterns)
+	}
+	slices.Sort(files)
+	return slices.Compact(files), nil
+}
+
+// parseQueries adds the named queries of the given file content to queries.
+func parseQueries(file, content string, queries map[string]namedQuery) error {
+	var current *namedQuery
+	var body strings.Builder
+	flush := func() error {
+		if current == nil {
+			return nil
+		}
+		current.sql = strings.TrimSpace(body.String())
+		if isEmpty(current.sql) {
+			return fmt.Errorf("pgc: query %q at %s:%d is empty", current.name, file, current.line)
+		}
+		queries[current.name] = *current
+		body.Reset()
+		return nil
+	}
+
+	for i, line := range strings.Split(content, "\n") {
+		m := queryNameMarker.FindStringSubmatch(line)
+		if m == nil {
+			if current != nil {
+				body.WriteString(strings.TrimRight(line, "\r"))
+				body.WriteByte('\n')
+			}
+			continue
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+		if prev, ok := queries[m[1]]; ok {
+			return fmt.Errorf("pgc: duplicate query name %q at %s:%d, first defined at %s:%d", m[1], file, i+1, prev.file, prev.line)
+		}
+		current = &namedQuery{name: m[1], file: file, line: i + 1}
+	}
+	return flush()
+}
+
+//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
+// Named Query Execution
+// The query name is used as funcName, so that inspections and their
+// statistics are grouped by logical query rather than by caller.
+//_______________________________________________________________________
+
+// LoadQueries loads the named SQL queries of the files of fsys matching the given patterns
+// (see NewQueryRegistry) and attaches the resulting registry to the Datasource.
+//
+// It is meant to be called at startup: a bad request is returned, and the current registry is kept,
+// when the files are invalid or a query name is defined more than once.
+//
+// When the Datasource is in debugging mode, the files are checked for changes before every named
+// execution and reloaded when they were modified, so that edits made to files served by os.DirFS are
+// picked up without a restart.
+//
+// Parameters:
+//   - fsys:     The file system holding the queries, e.g. an embed.FS or os.DirFS("sql").
+//   - patterns: Optional fs.Glob patterns selecting the files.
+//
+// Returns:
+//   - A wrapify.R instance describing the outcome, with the number of loaded queries as total.
+//
+// Example:
+//
+//	if response := ds.LoadQueries(sqlFiles, "sql/*.sql"); response.IsError() {
+//	    log.Fatal(response.Error())
+//	}
+//	var user User
+//	response := ds.GetByName(ctx, &user, "GetUserByID", 42)
+func (d *Datasource) LoadQueries(fsys fs.FS, patterns ...string) wrapify.R {
+	queries, err := NewQueryRegistry(fsys, patterns...)
+	if err != nil {
+		response := wrapify.WrapBadRequest("An error occurred while loading the named queries", nil).
+			WithDebuggingKV("patterns", patterns).
+			WithErrSck(err).
+			Reply()
+		d.dispatchEvent(EventQueryRegistry, EventLevelError, response)
+		return response
+	}
+	d.SetQueries(queries)
+	response := wrapify.WrapOk(fmt.Sprintf("Loaded %d named queries successfully", queries.Len()), queries.Names()).
+		WithTotal(queries.Len()).
+		Reply()
+	d.dispatchEvent(EventQueryRegistry, EventLevelSuccess, response)
+	return response
+}
+
+// ExecByName executes the named query of the attached registry, which must not return rows.
+// The query is inspected under its name. See ExecCtx for details.
+func (d *Datasource) ExecByName(ctx context.Context, name string, args ...any) (sql.Result, wrapify.R) {
+	return execByName(ctx, d, name, args)
+}
+
+// SelectByName executes the named query of the attached registry and scans all resulting rows into dest.
+// The query is inspected under its name. See SelectCtx for details.
+func (d *Datasource) SelectByName(ctx context.Context, dest any, name string, args ...any) wrapify.R {
+	return selectByName(ctx, d, dest, name, args)
+}
+
+// GetByName executes the named query of the attached registry and scans the first resulting row into dest.
+// The query is inspected under its name. See GetCtx for details.
+func (d *Datasource) GetByName(ctx context.Context, dest any, name string, args ...any) wrapify.R {
+	return getByName(ctx, d, dest, name, args)
+}
+
+// ExecByName executes the named query of the registry attached to the Datasource within the transaction.
+func (t *Transaction) ExecByName(ctx context.Context, name string, args ...any) (sql.Result, wrapify.R) {
+	return execByName(ctx, t, name, args)
+}
+
+// SelectByName executes the named query of the registry attached to the Datasource within the transaction
+// and scans all resulting rows into dest.
+func (t *Transaction) SelectByName(ctx context.Context, dest any, name string, args ...any) wrapify.R {
+	return selectByName(ctx, t, dest, name, args)
+}
+
+// GetByName executes the named query of the registry attached to the Datasource within the transaction
+// and scans the first resulting row into dest.
+func (t *Transaction) GetByName(ctx context.Context, dest any, name string, args ...any) wrapify.R {
+	return getByName(ctx, t, dest, name, args)
+}
+
+// execByName, selectByName and getByName resolve the named query and run it on the given
+// Executor through the inspected execution path, using the name as funcName.
+func execByName(ctx context.Context, exec Executor, name string, args []any) (sql.Result, wrapify.R) {
+	ds, ext, response, ok := exec.executor()
+	if !ok {
+		return nil, response
+	}
+	query, response, ok := ds.namedQuery(name)
+	if !ok {
+		return nil, response
+	}
+	return ds.execContext(ctx, ext, name, query, args)
+}
+
+func selectByName(ctx context.Context, exec Executor, dest any, name string, args []any) wrapify.R {
+	ds, ext, response, ok := exec.executor()
+	if !ok {
+		return response
+	}
+	query, response, ok := ds.namedQuery(name)
+	if !ok {
+		return response
+	}
+	return ds.selectContext(ctx, ext, name, dest, query, args)
+}
+
+func getByName(ctx context.Context, exec Executor, dest any, name string, args []any) wrapify.R {
+	ds, ext, response, ok := exec.executor()
+	if !ok {
+		return response
+	}
+	query, response, ok := ds.namedQuery(name)
+	if !ok {
+		return response
+	}
+	return ds.getContext(ctx, ext, name, dest, query, args)
+}
+
+// namedQuery returns the SQL of the named query of the attached registry, reloading the
+// registry first when the Datasource is in debugging mode and its files have changed.
+func (d *Datasource) namedQuery(name string) (string, wrapify.R, bool) {
+	queries := d.Queries()
+	if queries == nil {
+		response := wrapify.WrapBadRequest("No query registry is attached to the datasource", nil).BindCause().Reply()
+		d.dispatchEvent(EventQueryRegistry, EventLevelError, response)
+		return "", response, false
+	}
+	if d.conf.IsDebugging() {
+		d.reloadQueries(queries)
+	}
+	query, ok := queries.Query(name)
+	if !ok {
+		response := wrapify.WrapNotFound(fmt.Sprintf("Named query '%s' not found", name), nil).BindCause().Reply()
+		d.dispatchEvent(EventQueryRegistry, EventLevelError, response)
+		return "", response, false
+	}
+	return query, wrapify.WrapOk(fmt.Sprintf("Resolved named query '%s'", name), nil).Reply(), true
+}
+
+// reloadQueries reloads the registry when its files have changed. A failed reload keeps the
+// previously loaded queries and is reported as a warning.
+func (d *Datasource) reloadQueries(queries *QueryRegistry) {
+	changed, err := queries.changed()
+	if err == nil && !changed {
+		return
+	}
+	if err == nil {
+		err = queries.Reload()
+	}
+	if err != nil {
+		response := wrapify.WrapBadRequest("An error occurred while reloading the named queries; keeping the previous ones", nil).
+			WithErrSck(err).
+			Reply()
+		d.dispatchEvent(EventQueryRegistry, EventLevelWarn, response)
+		return
+	}
+	response := wrapify.WrapOk(fmt.Sprintf("Reloaded %d named queries successfully", queries.Len()), queries.Names()).
+		WithTotal(queries.Len()).
+		Reply()
+	d.dispatchEvent(EventQueryRegistry, EventLevelSuccess, response)
+}
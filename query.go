@@ -0,0 +1,97 @@
+package pgc
+
+import (
+	"context"
+
+	"github.com/sivaosorg/wrapify"
+)
+
+// QueryAll executes a query on the given Executor (a *Datasource or a *Transaction) and scans
+// every resulting row into a slice of T.
+//
+// T is typically a struct whose fields are mapped through `db` tags, but any type supported by
+// sqlx scanning (e.g. string, int64, time.Time) can be used for single-column results. The query is
+// timed through the datasource's Inspect and an EventQuerySelect event is dispatched with the outcome.
+//
+// Parameters:
+//   - ctx:   The context controlling cancellation and deadlines.
+//   - exec:  The *Datasource or *Transaction to run the query on.
+//   - query: The SQL query with PostgreSQL-style placeholders.
+//   - args:  The values bound to the placeholders.
+//
+// Returns:
+//   - The scanned rows (nil on failure, empty when the query returns no rows).
+//   - A wrapify.R instance describing the outcome, with the number of rows as total.
+//
+// Example:
+//
+//	users, response := pgc.QueryAll[User](ctx, ds, "SELECT id, name FROM users WHERE active = $1", true)
+//	if response.IsError() {
+//	    return response
+//	}
+func QueryAll[T any](ctx context.Context, exec Executor, query string, args ...any) ([]T, wrapify.R) {
+	ds, ext, response, ok := exec.executor()
+	if !ok {
+		return nil, response
+	}
+	var out []T
+	response = ds.selectContext(ctx, ext, "QueryAll", &out, query, args)
+	if response.IsError() {
+		return nil, response
+	}
+	return out, response
+}
+
+// QueryOne executes a query on the given Executor (a *Datasource or a *Transaction) and scans
+// the first resulting row into a value of T.
+//
+// When the query returns no rows, the zero value of T is returned together with a not found response.
+// The query is timed through the datasource's Inspect and an EventQueryGet event is dispatched with the outcome.
+//
+// Parameters:
+//   - ctx:   The context controlling cancellation and deadlines.
+//   - exec:  The *Datasource or *Transaction to run the query on.
+//   - query: The SQL query with PostgreSQL-style placeholders.
+//   - args:  The values bound to the placeholders.
+//
+// Returns:
+//   - The scanned row (the zero value of T on failure).
+//   - A wrapify.R instance describing the outcome.
+//
+// Example:
+//
+//	user, response := pgc.QueryOne[User](ctx, tx, "SELECT id, name FROM users WHERE id = $1", id)
+//	if response.StatusCode() == http.StatusNotFound {
+//	    // no such user
+//	}
+func QueryOne[T any](ctx context.Context, exec Executor, query string, args ...any) (T, wrapify.R) {
+	return queryRow[T](ctx, exec, "QueryOne", query, args)
+}
+
+// QueryScalar executes a query returning a single column on the given Executor and scans the value
+// of the first row into T (e.g. a COUNT(*), an EXISTS or a RETURNING id).
+//
+// It behaves like QueryOne, including the not found response when the query returns no rows, but
+// is inspected under its own function name so that scalar lookups are easy to tell apart.
+//
+// Example:
+//
+//	total, response := pgc.QueryScalar[int64](ctx, ds, "SELECT COUNT(*) FROM users")
+func QueryScalar[T any](ctx context.Context, exec Executor, query string, args ...any) (T, wrapify.R) {
+	return queryRow[T](ctx, exec, "QueryScalar", query, args)
+}
+
+// queryRow scans the first row of a query into a value of T, inspected under funcName.
+func queryRow[T any](ctx context.Context, exec Executor, funcName, query string, args []any) (T, wrapify.R) {
+	var out T
+	ds, ext, response, ok := exec.executor()
+	if !ok {
+		return out, response
+	}
+	response = ds.getContext(ctx, ext, funcName, &out, query, args)
+	if response.IsError() {
+		var zero T
+		return zero, response
+	}
+	return out, response
+}
@@ -0,0 +1,256 @@
+package pgc
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sivaosorg/wrapify"
+)
+
+// NewRegistry builds a Registry from named configurations, typically loaded from a single YAML file.
+// Every entry is bound with Bind and connected with NewClient; the entries are connected in parallel.
+//
+// The Optional flag of each entry decides how a failed connection is handled:
+//   - A required entry that is enabled but cannot connect aborts the startup: every Datasource already
+//     created is closed and an error response listing the failed entries is returned.
+//   - An optional entry that cannot connect is registered anyway and keeps retrying in the background,
+//     with an exponential backoff capped at its ping interval, until it connects or the registry is closed.
+//     Each attempt is reported as an EventConnRetry event of that Datasource.
+//
+// Disabled entries are registered as unavailable Datasources and never retried.
+//
+// Parameters:
+//   - confs: The configurations by datasource name.
+//
+// Returns:
+//   - A pointer to the Registry (nil when a required entry failed).
+//   - A wrapify.R instance describing the outcome, with the number of datasources as total.
+//
+// Example:
+//
+//	var conf struct {
+//	    Databases map[string]pgc.WConf `yaml:"databases"`
+//	}
+//	// ... unmarshal the YAML file into conf ...
+//	registry, response := pgc.NewRegistry(conf.Databases)
+//	if response.IsError() {
+//	    log.Fatal(response.Error())
+//	}
+//	defer registry.Close()
+//	billing, ok := registry.Get("billing")
+func NewRegistry(confs map[string]WConf) (*Registry, wrapify.R) {
+	start := time.Now()
+	r := &Registry{
+		sources:  make(map[string]*Datasource, len(confs)),
+		optional: make(map[string]bool, len(confs)),
+		stop:     make(chan struct{}),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, c := range confs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ds := NewClient(*Bind(&c))
+			mu.Lock()
+			defer mu.Unlock()
+			r.sources[name] = ds
+			r.optional[name] = c.Optional
+		}()
+	}
+	wg.Wait()
+
+	var failed []string
+	var causes []error
+	for _, name := range r.Names() {
+		ds := r.sources[name]
+		if !ds.conf.IsEnabled() || ds.IsConnected() || r.optional[name] {
+			continue
+		}
+		failed = append(failed, name)
+		causes = append(causes, fmt.Errorf("%s: %s", name, ds.State().Message()))
+	}
+	if len(failed) > 0 {
+		r.Close()
+		response := wrapify.WrapInternalServerError(fmt.Sprintf("Unable to connect the required datasources %v", failed), nil).
+			WithDebuggingKV("failed", failed).
+			WithDebuggingKV("executed_in", time.Since(start).String()).
+			WithErrSck(errors.Join(causes...)).
+			WithHeader(wrapify.InternalServerError).
+			Reply()
+		return nil, response
+	}
+
+	for name, ds := range r.sources {
+		if ds.conf.IsEnabled() && !ds.IsConnected() {
+			ds.setup()
+			r.wg.Add(1)
+			go r.retry(name, ds)
+		}
+	}
+	response := wrapify.WrapOk(fmt.Sprintf("Registered %d datasources successfully", len(r.sources)), r.Names()).
+		WithDebuggingKV("executed_in", time.Since(start).String()).
+		WithTotal(len(r.sources)).
+		Reply()
+	return r, response
+}
+
+// Get returns the Datasource registered under name.
+// An optional Datasource that is still retrying is returned as well; check IsConnected before use.
+func (r *Registry) Get(name string) (*Datasource, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	ds, ok := r.sources[name]
+	return ds, ok
+}
+
+// Names returns the names of the registered Datasources, sorted alphabetically.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Sorted(maps.Keys(r.sources))
+}
+
+// Health reports the health of every registered Datasource.
+//
+// The response is successful when every enabled required Datasource is connected, even if optional
+// ones are still retrying (reported with the "degraded" debugging key), and a service unavailable
+// response otherwise. The per-datasource health is returned as the response body as well.
+//
+// Returns:
+//   - The health of every Datasource, sorted by name.
+//   - A wrapify.R instance describing the aggregated health.
+func (r *Registry) Health() ([]DatasourceHealth, wrapify.R) {
+	var health []DatasourceHealth
+	var down, degraded []string
+	for _, name := range r.Names() {
+		ds, _ := r.Get(name)
+		r.mu.RLock()
+		optional := r.optional[name]
+		r.mu.RUnlock()
+		h := DatasourceHealth{
+			Name:      name,
+			Enabled:   ds.conf.IsEnabled(),
+			Optional:  optional,
+			Connected: ds.IsConnected(),
+			Message:   ds.State().Message(),
+		}
+		health = append(health, h)
+		if h.Enabled && !h.Connected {
+			if h.Optional {
+				degraded = append(degraded, name)
+			} else {
+				down = append(down, name)
+			}
+		}
+	}
+
+	if len(down) > 0 {
+		response := wrapify.WrapServiceUnavailable(fmt.Sprintf("Required datasources %v are unavailable", down), health).
+			WithDebuggingKV("down", down).
+			WithDebuggingKV("degraded", degraded).
+			WithTotal(len(health)).
+			WithHeader(wrapify.ServiceUnavailable).
+			Reply()
+		return health, response
+	}
+	response := wrapify.New().
+		WithStatusCode(http.StatusOK).
+		WithMessagef("%d datasources healthy, %d degraded", len(health)-len(degraded), len(degraded)).
+		WithBody(health).
+		WithDebuggingKV("degraded", degraded).
+		WithTotal(len(health)).
+		WithHeader(wrapify.OK).
+		Reply()
+	return health, response
+}
+
+// PoolStats returns the connection pool, worker pool and statement cache statistics of every
+// registered Datasource, by name.
+func (r *Registry) PoolStats() map[string]DatasourcePoolStats {
+	stats := make(map[string]DatasourcePoolStats)
+	for _, name := range r.Names() {
+		ds, _ := r.Get(name)
+		var s DatasourcePoolStats
+		if conn := ds.Conn(); conn != nil {
+			s.Conn = conn.Stats()
+		}
+		s.Event, s.Inspect = ds.PoolStats()
+		s.StmtCache = ds.StmtCacheStats()
+		stats[name] = s
+	}
+	return stats
+}
+
+// Close stops the background connection retries and closes every registered Datasource in parallel.
+//
+// Returns:
+//   - The errors reported while closing the Datasources, joined and prefixed with their names.
+func (r *Registry) Close() error {
+	r.once.Do(func() { close(r.stop) })
+	r.wg.Wait()
+
+	r.mu.RLock()
+	sources := maps.Clone(r.sources)
+	r.mu.RUnlock()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var errs []error
+	for name, ds := range sources {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := ds.Close(); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s: %w", name, err))
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// retry reconnects an optional Datasource in the background until it succeeds or the registry
+// is closed, doubling the delay between attempts up to the datasource's ping interval.
+func (r *Registry) retry(name string, ds *Datasource) {
+	defer r.wg.Done()
+	maxBackoff := ds.conf.PingInterval()
+	if maxBackoff <= 0 {
+		maxBackoff = defaultPingInterval
+	}
+	backoff := min(defaultRegistryRetryBackoff, maxBackoff)
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-r.stop:
+			return
+		case <-time.After(backoff):
+		}
+
+		start := time.Now()
+		if err := ds.reconnect(); err != nil {
+			backoff = min(backoff*2, maxBackoff)
+			response := wrapify.WrapServiceUnavailable(fmt.Sprintf("Optional datasource '%s' is still unreachable", name), nil).
+				WithDebuggingKV("pgsql_conn_str", ds.conf.String(true)).
+				WithDebuggingKV("attempt", attempt).
+				WithDebuggingKV("next_attempt_in", backoff.String()).
+				WithErrSck(err).
+				WithHeader(wrapify.ServiceUnavailable).
+				Reply()
+			ds.SetState(response)
+			ds.dispatchEvent(EventConnRetry, EventLevelWarn, response)
+			continue
+		}
+		ds.activate(start)
+		ds.dispatchEvent(EventConnRetry, EventLevelSuccess, ds.State())
+		ds.dispatchReconnect(ds.State(), ds)
+		return
+	}
+}
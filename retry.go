@@ -0,0 +1,186 @@
+package pgc
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sivaosorg/wrapify"
+)
+
+// writeRetryKey is the context key marking the statements whose writes may be retried.
+type writeRetryKey struct{}
+
+// WithWriteRetry returns a copy of ctx marking the statements run with it as idempotent, so that the
+// retry policy of the Datasource also applies to writes (INSERT, UPDATE, DELETE, ...), which are
+// otherwise never retried. Only use it for statements that can safely run more than once: a write
+// failing with a connection error may have been committed before the connection was lost.
+//
+// Example:
+//
+//	_, response := ds.ExecCtx(pgc.WithWriteRetry(ctx),
+//	    "UPDATE accounts SET status = $1 WHERE id = $2", "closed", id)
+func WithWriteRetry(ctx context.Context) context.Context {
+	return context.WithValue(ctx, writeRetryKey{}, true)
+}
+
+// IsTransientErr reports whether err is a transient failure that a retry may overcome: a connection
+// exception (SQLSTATE class 08, such as 08006 connection_failure), an admin or crash shutdown (57P01,
+// 57P02), a server that cannot accept connections yet (57P03), too many connections (53300), or a
+// broken network connection reported by the driver. Context cancellations are never transient.
+func IsTransientErr(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	switch code := sqlState(err); {
+	case code == sqlStateAdminShutdown, code == sqlStateCrashShutdown, code == sqlStateCannotConnectNow,
+		code == sqlStateTooManyConnections, strings.HasPrefix(code, sqlClassConnectionException):
+		return true
+	case code != "":
+		return false
+	}
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.As(err, &netErr)
+}
+
+// retry runs fn and, while it fails with an error the retry policy deems transient, runs it again
+// after an exponential, jittered delay, up to the maximum number of attempts. Every retry is reported
+// as an EventConnRetry event. Every attempt runs through the circuit breaker (see guard), so that no
+// attempt is made while it is open.
+//
+// Statements run within a transaction (ext is a *sqlx.Tx) are never retried, and statements that
+// are not known to be reads (see isReadQuery) are only retried when ctx is marked with WithWriteRetry.
+func (d *Datasource) retry(ctx context.Context, ext sqlx.ExtContext, funcName string, read bool, fn func() error) error {
+	err := d.guard(ctx, fn)
+	policy := d.conf.RetryPolicy()
+	if err == nil || policy == nil {
+		return err
+	}
+	if _, inTx := ext.(*sqlx.Tx); inTx {
+		return err
+	}
+	if write, _ := ctx.Value(writeRetryKey{}).(bool); !write && !read {
+		return err
+	}
+
+	for attempt := 1; attempt < policy.MaxAttempts() && policy.IsRetryable(err); attempt++ {
+		delay := retryDelay(policy, attempt)
+		response := wrapify.WrapServiceUnavailable(fmt.Sprintf("Retrying '%s' after a transient error (attempt %d of %d)", funcName, attempt+1, policy.MaxAttempts()), nil).
+			WithDebuggingKV("func_name", funcName).
+			WithDebuggingKV("attempt", attempt+1).
+			WithDebuggingKV("max_attempts", policy.MaxAttempts()).
+			WithDebuggingKV("delay", delay.String()).
+			WithDebuggingKV("sqlstate", sqlState(err)).
+			WithErrSck(err).
+			Reply()
+		d.dispatchEvent(EventConnRetry, EventLevelWarn, response)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if err = d.guard(ctx, fn); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// retryDelay returns the delay before the given retry (1 for the first one): the initial backoff
+// doubled for each previous retry, capped by the maximum backoff, with its jitter fraction randomized.
+func retryDelay(policy *RetryPolicy, attempt int) time.Duration {
+	delay, maxBackoff := policy.Backoff(), policy.MaxBackoff()
+	for i := 1; i < attempt && (maxBackoff <= 0 || delay < maxBackoff); i++ {
+		delay *= 2
+	}
+	if maxBackoff > 0 && delay > maxBackoff {
+		delay = maxBackoff
+	}
+	if jitter := policy.Jitter(); jitter > 0 && delay > 0 {
+		spread := time.Duration(float64(delay) * jitter)
+		delay = delay - spread + rand.N(spread+1)
+	}
+	return delay
+}
+
+// isReadQuery reports whether the statement only reads data, judging from its first keyword
+// (SELECT, SHOW, VALUES, TABLE or EXPLAIN). Statements starting with WITH are treated as writes,
+// since their common table expressions may modify data.
+func isReadQuery(query string) bool {
+	query = strings.TrimLeft(query, " \t\r\n(")
+	end := strings.IndexFunc(query, func(r rune) bool {
+		return !('a' <= r && r <= 'z' || 'A' <= r && r <= 'Z')
+	})
+	if end >= 0 {
+		query = query[:end]
+	}
+	switch strings.ToUpper(query) {
+	case "SELECT", "SHOW", "VALUES", "TABLE", "EXPLAIN":
+		return true
+	}
+	return false
+}
+
+// resetDest empties the slice pointed to by dest, so that a retried select does not append
+// the rows of a failed attempt twice.
+func resetDest(dest any) {
+	if rv := reflect.ValueOf(dest); rv.Kind() == reflect.Pointer && !rv.IsNil() && rv.Elem().Kind() == reflect.Slice {
+		rv.Elem().SetLen(0)
+	}
+}
+
+// reader returns the connection pool wrapped so that the queries of the metadata call funcName,
+// which only read the catalog, are retried according to the retry policy.
+func (d *Datasource) reader(funcName string) *retryDB {
+	return &retryDB{DB: d.Conn(), ds: d, funcName: funcName}
+}
+
+// SelectContext runs sqlx.SelectContext, retrying it on transient errors.
+func (r *retryDB) SelectContext(ctx context.Context, dest any, query string, args ...any) error {
+	return r.ds.retry(ctx, r.DB, r.funcName, true, func() error {
+		resetDest(dest)
+		return r.DB.SelectContext(ctx, dest, query, args...)
+	})
+}
+
+// GetContext runs sqlx.GetContext, retrying it on transient errors.
+func (r *retryDB) GetContext(ctx context.Context, dest any, query string, args ...any) error {
+	return r.ds.retry(ctx, r.DB, r.funcName, true, func() error {
+		return r.DB.GetContext(ctx, dest, query, args...)
+	})
+}
+
+// QueryContext runs the query, retrying it on transient errors.
+func (r *retryDB) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	var rows *sql.Rows
+	err := r.ds.retry(ctx, r.DB, r.funcName, true, func() (err error) {
+		rows, err = r.DB.QueryContext(ctx, query, args...)
+		return err
+	})
+	return rows, err
+}
+
+// QueryRowContext runs the query, retrying it while the row reports a transient error.
+func (r *retryDB) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	var row *sql.Row
+	_ = r.ds.retry(ctx, r.DB, r.funcName, true, func() error {
+		row = r.DB.QueryRowContext(ctx, query, args...)
+		return row.Err()
+	})
+	return row
+}
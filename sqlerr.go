@@ -0,0 +1,99 @@
+package pgc
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/lib/pq"
+)
+
+// ClassifyErr unwraps the *pq.Error carried by err and returns its category, from which the status
+// code of the responses describing the error is derived (see ErrCategory.StatusCode).
+//
+// Integrity constraint violations, insufficient privileges, undefined tables, cancelled statements
+// (e.g. by statement_timeout) and serialization failures are recognised from their SQLSTATE. Connection
+// failures, including the driver and network errors carrying no SQLSTATE and the operations rejected by
+// the circuit breaker, are recognised as ErrCategoryConnectionFailure. Any other error is ErrCategoryUnknown.
+//
+// Parameters:
+//   - `err`: The error to classify. Wrapped errors are unwrapped using errors.As.
+//
+// Returns:
+//
+//	The category of the error.
+//
+// Example:
+//
+//	_, response := ds.ExecCtx(ctx, "INSERT INTO users (email) VALUES ($1)", email)
+//	if response.IsError() && pgc.ClassifyErr(response.Cause()) == pgc.ErrCategoryUniqueViolation {
+//	    // the email is already taken
+//	}
+func ClassifyErr(err error) ErrCategory {
+	switch sqlState(err) {
+	case sqlStateUniqueViolation:
+		return ErrCategoryUniqueViolation
+	case sqlStateForeignKeyViolation:
+		return ErrCategoryForeignKeyViolation
+	case sqlStateCheckViolation:
+		return ErrCategoryCheckViolation
+	case sqlStateNotNullViolation:
+		return ErrCategoryNotNullViolation
+	case sqlStateInsufficientPriv:
+		return ErrCategoryInsufficientPrivilege
+	case sqlStateUndefinedTable:
+		return ErrCategoryUndefinedTable
+	case sqlStateQueryCanceled:
+		return ErrCategoryQueryCanceled
+	case sqlStateSerializationFailure, sqlStateDeadlockDetected:
+		return ErrCategorySerializationFailure
+	}
+	if errors.Is(err, ErrCircuitOpen) || IsTransientErr(err) {
+		return ErrCategoryConnectionFailure
+	}
+	return ErrCategoryUnknown
+}
+
+// StatusCode returns the HTTP status code reported for the errors of the category:
+// 409 for unique and foreign key violations and serialization failures, 400 for check and
+// not-null violations, 403 for insufficient privileges, 404 for undefined tables, 408 for
+// cancelled statements, 503 for connection failures and 500 for any other error.
+func (c ErrCategory) StatusCode() int {
+	switch c {
+	case ErrCategoryUniqueViolation, ErrCategoryForeignKeyViolation, ErrCategorySerializationFailure:
+		return http.StatusConflict
+	case ErrCategoryCheckViolation, ErrCategoryNotNullViolation:
+		return http.StatusBadRequest
+	case ErrCategoryInsufficientPrivilege:
+		return http.StatusForbidden
+	case ErrCategoryUndefinedTable:
+		return http.StatusNotFound
+	case ErrCategoryQueryCanceled:
+		return http.StatusRequestTimeout
+	case ErrCategoryConnectionFailure:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// pqErrFields returns the diagnostic fields of the *pq.Error carried by err that are set
+// (sqlstate, constraint, table, column and detail), keyed by their debugging key, or nil
+// when err does not originate from the driver.
+func pqErrFields(err error) map[string]string {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return nil
+	}
+	fields := map[string]string{"sqlstate": string(pqErr.Code)}
+	for key, value := range map[string]string{
+		"constraint": pqErr.Constraint,
+		"table":      pqErr.Table,
+		"column":     pqErr.Column,
+		"detail":     pqErr.Detail,
+	} {
+		if isNotEmpty(value) {
+			fields[key] = value
+		}
+	}
+	return fields
+}
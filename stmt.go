@@ -0,0 +1,235 @@
+package pgc
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sivaosorg/wrapify"
+)
+
+// newStmtCache creates a prepared statement cache holding at most capacity statements.
+// It returns nil when capacity is not positive, which disables the cache.
+func newStmtCache(capacity int) *stmtCache {
+	if capacity <= 0 {
+		return nil
+	}
+	return &stmtCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element, capacity),
+	}
+}
+
+// ext returns the sqlx handle used by the inspected execution methods of the Datasource:
+// a statement-caching wrapper around the connection when the cache is enabled, or the
+// connection itself otherwise. Queries without arguments always bypass the cache, since they
+// may be multi-statement scripts which PostgreSQL refuses to prepare (SQLSTATE 42601).
+func (d *Datasource) ext() sqlx.ExtContext {
+	conn := d.Conn()
+	if d.stmtCache == nil {
+		return conn
+	}
+	return &stmtExt{DB: conn, ds: d, cache: d.stmtCache}
+}
+
+// ExecContext executes the query through its cached prepared statement.
+func (s *stmtExt) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	if len(args) == 0 {
+		return s.DB.ExecContext(ctx, query)
+	}
+	var result sql.Result
+	err := s.run(ctx, query, func(stmt *sqlx.Stmt) (err error) {
+		result, err = stmt.ExecContext(ctx, args...)
+		return err
+	})
+	return result, err
+}
+
+// QueryContext runs the query through its cached prepared statement.
+func (s *stmtExt) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	rows, err := s.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return rows.Rows, nil
+}
+
+// QueryxContext runs the query through its cached prepared statement.
+// The returned rows keep the statement alive until they are closed.
+func (s *stmtExt) QueryxContext(ctx context.Context, query string, args ...any) (*sqlx.Rows, error) {
+	if len(args) == 0 {
+		return s.DB.QueryxContext(ctx, query)
+	}
+	var rows *sqlx.Rows
+	err := s.run(ctx, query, func(stmt *sqlx.Stmt) (err error) {
+		rows, err = stmt.QueryxContext(ctx, args...)
+		return err
+	})
+	return rows, err
+}
+
+// QueryRowxContext runs the query through its cached prepared statement.
+// Errors surfaced while preparing or executing are reported through the returned row, as with sqlx.
+func (s *stmtExt) QueryRowxContext(ctx context.Context, query string, args ...any) *sqlx.Row {
+	if len(args) == 0 {
+		return s.DB.QueryRowxContext(ctx, query)
+	}
+	var row *sqlx.Row
+	_ = s.run(ctx, query, func(stmt *sqlx.Stmt) error {
+		row = stmt.QueryRowxContext(ctx, args...)
+		return row.Err()
+	})
+	if row == nil {
+		// Preparing failed: run the query directly so that sqlx builds a row carrying the error.
+		return s.DB.QueryRowxContext(ctx, query, args...)
+	}
+	return row
+}
+
+// run invokes fn with the cached prepared statement of query. When the statement's cached plan
+// is broken by a schema change (SQLSTATE 0A000 "cached plan must not change result type"), the
+// statement is prepared again, an EventStmtReprepare event is dispatched and fn is retried once.
+func (s *stmtExt) run(ctx context.Context, query string, fn func(stmt *sqlx.Stmt) error) error {
+	entry, err := s.cache.acquire(ctx, s.DB, query)
+	if err != nil {
+		return err
+	}
+	err = fn(entry.stmt)
+	s.cache.release(entry)
+	if err == nil || !isCachedPlanErr(err) {
+		return err
+	}
+
+	cause := err
+	s.cache.remove(query)
+	s.cache.reprepares.Add(1)
+	entry, err = s.cache.acquire(ctx, s.DB, query)
+	if err != nil {
+		response := wrapify.WrapInternalServerError("Failed to re-prepare statement after its cached plan broke", nil).
+			WithDebuggingKV("query", query).
+			WithDebuggingKV("cause", cause.Error()).
+			WithErrSck(err).
+			Reply()
+		s.ds.dispatchEvent(EventStmtReprepare, EventLevelError, response)
+		return err
+	}
+	response := wrapify.WrapOk("Statement re-prepared after its cached plan broke", nil).
+		WithDebuggingKV("query", query).
+		WithDebuggingKV("cause", cause.Error()).
+		Reply()
+	s.ds.dispatchEvent(EventStmtReprepare, EventLevelWarn, response)
+	defer s.cache.release(entry)
+	return fn(entry.stmt)
+}
+
+// acquire returns the cache entry of query, preparing and caching the statement on a miss,
+// and marks it as in use until release is called. When the cache is full, the least recently
+// used entry is evicted.
+func (c *stmtCache) acquire(ctx context.Context, db *sqlx.DB, query string) (*stmtCacheEntry, error) {
+	c.mu.Lock()
+	if el, ok := c.items[query]; ok {
+		entry := el.Value.(*stmtCacheEntry)
+		if entry.db == db {
+			c.ll.MoveToFront(el)
+			entry.refs++
+			c.mu.Unlock()
+			c.hits.Add(1)
+			return entry, nil
+		}
+		// The statement was prepared on a connection that has since been replaced.
+		c.evict(el)
+	}
+	c.mu.Unlock()
+	c.misses.Add(1)
+
+	// Prepare outside the lock so that a slow round trip does not block other lookups.
+	stmt, err := db.PreparexContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[query]; ok {
+		if entry := el.Value.(*stmtCacheEntry); entry.db == db {
+			// Another goroutine cached the same query in the meantime; keep its statement.
+			stmt.Close()
+			c.ll.MoveToFront(el)
+			entry.refs++
+			return entry, nil
+		}
+		c.evict(el)
+	}
+	entry := &stmtCacheEntry{query: query, db: db, stmt: stmt, refs: 1}
+	c.items[query] = c.ll.PushFront(entry)
+	for c.ll.Len() > c.capacity {
+		c.evict(c.ll.Back())
+		c.evictions.Add(1)
+	}
+	return entry, nil
+}
+
+// release marks the entry as no longer used by the caller, closing its statement
+// if the entry was evicted in the meantime.
+func (c *stmtCache) release(entry *stmtCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.refs--
+	if entry.evicted && entry.refs == 0 {
+		entry.stmt.Close()
+	}
+}
+
+// remove evicts the cache entry of query, if any.
+func (c *stmtCache) remove(query string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[query]; ok {
+		c.evict(el)
+	}
+}
+
+// purge evicts every cache entry. It is invoked when the underlying connection is replaced
+// or closed, since the statements are bound to it. Calling purge on a nil cache is a no-op.
+func (c *stmtCache) purge() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for el := c.ll.Front(); el != nil; el = c.ll.Front() {
+		c.evict(el)
+	}
+}
+
+// evict removes the element from the cache and closes its statement, or defers the close
+// to the last release when the statement is still in use. The caller must hold c.mu.
+func (c *stmtCache) evict(el *list.Element) {
+	entry := el.Value.(*stmtCacheEntry)
+	c.ll.Remove(el)
+	delete(c.items, entry.query)
+	entry.evicted = true
+	if entry.refs == 0 {
+		entry.stmt.Close()
+	}
+}
+
+// stats returns a snapshot of the cache counters. A nil cache reports zero values.
+func (c *stmtCache) stats() StmtCacheStats {
+	if c == nil {
+		return StmtCacheStats{}
+	}
+	c.mu.Lock()
+	size := c.ll.Len()
+	c.mu.Unlock()
+	return StmtCacheStats{
+		Capacity:   c.capacity,
+		Size:       size,
+		Hits:       c.hits.Load(),
+		Misses:     c.misses.Load(),
+		Evictions:  c.evictions.Load(),
+		Reprepares: c.reprepares.Load(),
+	}
+}
@@ -0,0 +1,196 @@
+package pgc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync/atomic"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/sivaosorg/wrapify"
+)
+
+// cursorSeq numbers the server-side cursors opened by Stream so that concurrent streams
+// never collide on a cursor name.
+var cursorSeq atomic.Uint64
+
+// Stream executes a query through a server-side cursor and hands the resulting rows to fn one by one,
+// fetching them from the server in batches of batchSize rows.
+//
+// Unlike Queryx, which lets the driver buffer the whole result set, Stream declares a cursor
+// (DECLARE ... NO SCROLL CURSOR FOR query) and repeatedly issues FETCH FORWARD batchSize, so that
+// memory usage stays bounded regardless of the size of the result set. Because cursors only live
+// for the duration of a transaction, Stream is only available on Transaction.
+//
+// The callback receives the *sqlx.Rows positioned on the current row and may call Scan, StructScan
+// or MapScan on it; it must not call Next or Close. Returning ErrStreamStop from the callback stops the
+// stream early and is reported as a success; any other error aborts the stream and is reported as a failure.
+//
+// After each batch an EventTxStreamBatch event is dispatched with the "batch", "rows" and "streamed"
+// debugging key-values, and a final EventTxStream event reports the outcome of the whole stream.
+//
+// Parameters:
+//   - ctx:       The context controlling cancellation and deadlines.
+//   - query:     The SQL query with PostgreSQL-style placeholders.
+//   - args:      The values bound to the placeholders.
+//   - batchSize: The number of rows fetched per round trip (defaults to 1000 when <= 0).
+//   - fn:        The callback invoked for each row.
+//
+// Returns:
+//   - A wrapify.R instance describing the outcome, with the number of streamed rows as total.
+//
+// Example:
+//
+//	response := tx.Stream(ctx, "SELECT id, payload FROM events WHERE created_at > $1", []any{since}, 5000,
+//	    func(rows *sqlx.Rows) error {
+//	        var e Event
+//	        if err := rows.StructScan(&e); err != nil {
+//	            return err
+//	        }
+//	        return encoder.Encode(e)
+//	    })
+func (t *Transaction) Stream(ctx context.Context, query string, args []any, batchSize int, fn func(rows *sqlx.Rows) error) wrapify.R {
+	tx, response, ok := t.session()
+	if !ok {
+		return response
+	}
+	if fn == nil {
+		response := wrapify.WrapBadRequest("Stream callback is required", nil).BindCause()
+		t.ds.dispatchEvent(EventTxStream, EventLevelError, response.Reply())
+		return response.Reply()
+	}
+	if batchSize <= 0 {
+		batchSize = defaultStreamBatchSize
+	}
+	d := t.ds
+	cursor := fmt.Sprintf("%s%d", defaultCursorPrefix, cursorSeq.Add(1))
+	declare := fmt.Sprintf("DECLARE %s NO SCROLL CURSOR FOR %s", pq.QuoteIdentifier(cursor), query)
+
+	// Start inspection
+	done := d.Inspect("Stream-declare", declare, args...)
+	_, err := tx.ExecContext(ctx, declare, args...)
+	// End inspection
+	done()
+
+	if err != nil {
+		response := wrapQueryErr(ctx, err, "An error occurred while declaring the stream cursor", nil)
+		d.dispatchEvent(EventTxStream, queryErrLevel(ctx, err), response.Reply())
+		return response.Reply()
+	}
+	defer func() {
+		// Closing is best effort: a failed statement has already aborted the transaction,
+		// and the cursor is dropped at the end of the transaction anyway.
+		_, _ = tx.ExecContext(context.WithoutCancel(ctx), fmt.Sprintf("CLOSE %s", pq.QuoteIdentifier(cursor)))
+	}()
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM %s", batchSize, pq.QuoteIdentifier(cursor))
+	var streamed, batches int
+	var stopped bool
+	for {
+		count, stop, err := t.fetchBatch(ctx, tx, fetch, fn)
+		streamed += count
+		if err != nil {
+			response := wrapQueryErr(ctx, err, "An error occurred while streaming rows", nil).
+				WithDebuggingKV("cursor", cursor).
+				WithDebuggingKV("batches", batches).
+				WithDebuggingKV("streamed", streamed).
+				WithTotal(streamed).
+				Reply()
+			d.dispatchEvent(EventTxStream, queryErrLevel(ctx, err), response)
+			return response
+		}
+		if count > 0 {
+			batches++
+			progress := wrapify.WrapProcessing("Streamed batch of rows", nil).
+				WithDebuggingKV("cursor", cursor).
+				WithDebuggingKV("batch", batches).
+				WithDebuggingKV("rows", count).
+				WithDebuggingKV("streamed", streamed).
+				WithTotal(streamed).
+				Reply()
+			d.dispatchEvent(EventTxStreamBatch, EventLevelInfo, progress)
+		}
+		if stop {
+			stopped = true
+			break
+		}
+		if count < batchSize {
+			break
+		}
+	}
+
+	response = wrapify.WrapOk("Streamed rows successfully", nil).
+		WithDebuggingKV("cursor", cursor).
+		WithDebuggingKV("batches", batches).
+		WithDebuggingKV("stopped", stopped).
+		WithTotal(streamed).
+		Reply()
+	d.dispatchEvent(EventTxStream, EventLevelSuccess, response)
+	return response
+}
+
+// StreamAs is the typed variant of Transaction.Stream: each row is decoded into a value of T before
+// being handed to fn.
+//
+// Structs are decoded with StructScan using their `db` tags; any other type (e.g. string, int64,
+// time.Time or a sql.Scanner) is decoded with Scan and is expected to match a single-column result.
+// Returning ErrStreamStop from fn stops the stream early.
+//
+// Example:
+//
+//	response := pgc.StreamAs(ctx, tx, "SELECT id, email FROM users", nil, 1000, func(u User) error {
+//	    return mailer.Send(u.Email)
+//	})
+func StreamAs[T any](ctx context.Context, t *Transaction, query string, args []any, batchSize int, fn func(item T) error) wrapify.R {
+	if fn == nil {
+		return t.Stream(ctx, query, args, batchSize, nil)
+	}
+	scannable := isScannable(reflect.TypeFor[T]())
+	return t.Stream(ctx, query, args, batchSize, func(rows *sqlx.Rows) error {
+		var item T
+		var err error
+		if scannable {
+			err = rows.Scan(&item)
+		} else {
+			err = rows.StructScan(&item)
+		}
+		if err != nil {
+			return err
+		}
+		return fn(item)
+	})
+}
+
+// fetchBatch fetches a single batch from the stream cursor and passes each row to fn.
+//
+// Returns:
+//   - The number of rows handed to fn.
+//   - Whether fn requested the stream to stop with ErrStreamStop.
+//   - The error that aborted the batch, if any.
+func (t *Transaction) fetchBatch(ctx context.Context, tx *sqlx.Tx, fetch string, fn func(rows *sqlx.Rows) error) (int, bool, error) {
+	// Start inspection
+	done := t.ds.Inspect("Stream-fetch", fetch)
+	rows, err := tx.QueryxContext(ctx, fetch)
+	// End inspection
+	done()
+
+	if err != nil {
+		return 0, false, err
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		err := fn(rows)
+		if errors.Is(err, ErrStreamStop) {
+			return count + 1, true, nil
+		}
+		if err != nil {
+			return count, false, err
+		}
+		count++
+	}
+	return count, false, rows.Err()
+}
@@ -0,0 +1,454 @@
+package pgc
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sivaosorg/wrapify"
+)
+
+// Commit commits the transaction and marks it as inactive.
+//
+// The method is safe for concurrent use; the active flag is checked and updated under the
+// transaction's mutex so that a transaction can be completed exactly once. Calling Commit on a
+// transaction that was never started, or that has already been committed or rolled back,
+// returns a bad request response without touching the database.
+//
+// Whether or not the commit succeeds, the underlying *sqlx.Tx is no longer usable afterwards,
+// so the transaction is always marked as inactive once the commit has been attempted.
+//
+// For a nested transaction created through Begin, Commit releases the backing savepoint instead
+// of committing the outer transaction. A transaction refuses to commit while any of its nested
+// transactions are still open.
+//
+// Returns:
+//   - A wrapify.R instance describing the outcome of the commit. The same response is stored
+//     on the transaction and can later be retrieved through Wrap().
+func (t *Transaction) Commit() wrapify.R {
+	response, _ := t.commit()
+	return response
+}
+
+// Rollback aborts the transaction and marks it as inactive.
+//
+// Rollback is designed to be safe in deferred cleanups: calling it on a transaction that has
+// already been committed or rolled back does not reach the database and only reports a warning
+// event, which makes the common "defer tx.Rollback()" pattern harmless after a successful Commit.
+//
+// Returns:
+//   - A wrapify.R instance describing the outcome of the rollback. The same response is stored
+//     on the transaction and can later be retrieved through Wrap().
+func (t *Transaction) Rollback() wrapify.R {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.active || t.tx == nil {
+		response := wrapify.WrapBadRequest("Transaction is not active, rollback skipped", nil).BindCause().Reply()
+		t.ds.dispatchEvent(EventTxRollback, EventLevelWarn, t.describe(response))
+		return response
+	}
+	if t.parent != nil {
+		response, _ := t.rollbackNested()
+		return response
+	}
+
+	err := t.tx.Rollback()
+	t.active = false
+	if err != nil {
+		t.wrap = t.describe(wrapQueryErr(t.context(), err, "Failed to rollback transaction", nil))
+		t.ds.dispatchEvent(EventTxRollback, queryErrLevel(t.context(), err), t.wrap)
+		return t.wrap
+	}
+
+	t.wrap = t.describe(wrapify.WrapOk("Transaction rolled back successfully", nil).WithHeader(wrapify.OK).Reply())
+	t.ds.dispatchEvent(EventTxRollback, EventLevelSuccess, t.wrap)
+	return t.wrap
+}
+
+// Begin starts a nested transaction backed by an automatically named SAVEPOINT.
+//
+// The returned child shares the underlying *sqlx.Tx with its parent. Committing the child
+// releases the savepoint, keeping its changes as part of the parent transaction, while rolling
+// the child back only undoes the work performed since the savepoint was created. Children can be
+// nested further; the parent refuses to commit while any of its children are still open.
+//
+// The savepoint name and nesting depth are attached as the "savepoint" and "depth" debugging
+// key-values of the dispatched events.
+//
+// Parameters:
+//   - ctx: The context used to execute the SAVEPOINT statement.
+//
+// Returns:
+//   - A pointer to the child Transaction; check IsActivated() or Wrap() for the outcome.
+//
+// Example:
+//
+//	child := tx.Begin(ctx)
+//	if err := doWork(child); err != nil {
+//	    child.Rollback() // only undoes doWork
+//	} else {
+//	    child.Commit() // releases the savepoint
+//	}
+//	tx.Commit()
+func (t *Transaction) Begin(ctx context.Context) *Transaction {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	child := &Transaction{
+		ds:     t.ds,
+		tx:     t.tx,
+		parent: t,
+		depth:  t.depth + 1,
+		ctx:    t.ctx,
+	}
+	if !t.active || t.tx == nil {
+		child.wrap = child.describe(wrapify.WrapBadRequest("Transaction is not active, nested transaction not started", nil).BindCause().Reply())
+		t.ds.dispatchEvent(EventTxSavepointCreate, EventLevelError, child.wrap)
+		return child
+	}
+
+	t.seq++
+	if isEmpty(t.savepoint) {
+		child.savepoint = fmt.Sprintf("%s%d", defaultSavepointPrefix, t.seq)
+	} else {
+		child.savepoint = fmt.Sprintf("%s_%d", t.savepoint, t.seq)
+	}
+	query := "SAVEPOINT " + pq.QuoteIdentifier(child.savepoint)
+
+	// Start inspection
+	done := t.ds.Inspect("Begin", query)
+	_, err := t.tx.ExecContext(ctx, query)
+	// End inspection
+	done()
+
+	if err != nil {
+		child.wrap = child.describe(wrapQueryErr(ctx, err, "Failed to start nested transaction", nil))
+		t.ds.dispatchEvent(EventTxSavepointCreate, queryErrLevel(ctx, err), child.wrap)
+		return child
+	}
+
+	t.children++
+	child.active = true
+	child.wrap = child.describe(wrapify.WrapOk("Nested transaction started successfully", nil).WithHeader(wrapify.OK).Reply())
+	t.ds.dispatchEvent(EventTxSavepointCreate, EventLevelSuccess, child.wrap)
+	return child
+}
+
+// Savepoint establishes a new savepoint with the given name within the current transaction.
+//
+// The savepoint name is quoted as an identifier before being sent to PostgreSQL, so any
+// valid identifier (including mixed case names) can be used safely.
+//
+// Parameters:
+//   - name: The name of the savepoint to create.
+//
+// Returns:
+//   - A wrapify.R instance describing the outcome of the SAVEPOINT statement.
+func (t *Transaction) Savepoint(name string) wrapify.R {
+	return t.savepointExec(EventTxSavepointCreate, "Savepoint", "SAVEPOINT %s", name,
+		fmt.Sprintf("Savepoint '%s' created successfully", name),
+		fmt.Sprintf("Failed to create savepoint '%s'", name))
+}
+
+// RollbackTo rolls back all commands executed after the named savepoint was established.
+// The savepoint remains valid and can be rolled back to again later if needed.
+//
+// Parameters:
+//   - name: The name of the savepoint to roll back to.
+//
+// Returns:
+//   - A wrapify.R instance describing the outcome of the ROLLBACK TO SAVEPOINT statement.
+func (t *Transaction) RollbackTo(name string) wrapify.R {
+	return t.savepointExec(EventTxSavepointRollback, "RollbackTo", "ROLLBACK TO SAVEPOINT %s", name,
+		fmt.Sprintf("Rolled back to savepoint '%s' successfully", name),
+		fmt.Sprintf("Failed to rollback to savepoint '%s'", name))
+}
+
+// Release destroys the named savepoint, keeping the effects of commands executed after it.
+//
+// Parameters:
+//   - name: The name of the savepoint to release.
+//
+// Returns:
+//   - A wrapify.R instance describing the outcome of the RELEASE SAVEPOINT statement.
+func (t *Transaction) Release(name string) wrapify.R {
+	return t.savepointExec(EventTxSavepointRelease, "Release", "RELEASE SAVEPOINT %s", name,
+		fmt.Sprintf("Savepoint '%s' released successfully", name),
+		fmt.Sprintf("Failed to release savepoint '%s'", name))
+}
+
+// WithTx executes fn inside a transaction and completes the transaction automatically.
+//
+// The transaction is committed when fn returns nil and rolled back when fn returns an error or
+// panics. A panic raised by fn is re-panicked once the rollback has been issued, so the caller's
+// own recovery logic keeps working.
+//
+// When PostgreSQL aborts the transaction with SQLSTATE 40001 (serialization_failure) or 40P01
+// (deadlock_detected), either from within fn or at commit time, the whole closure is retried on a
+// brand new transaction using an exponential backoff. Because the closure may run more than once,
+// fn must not have side effects outside of the transaction. Each attempt is reported through an
+// EventTxAttempt event carrying the attempt number in the "attempt" debugging key.
+//
+// Parameters:
+//   - ctx:  The context used to begin the transaction and to interrupt the retry backoff.
+//   - opts: Optional transaction options (see BeginTxWith); nil uses the server defaults.
+//   - fn:   The function executed within the transaction.
+//
+// Returns:
+//   - A wrapify.R instance describing the final outcome of the transaction.
+//
+// Example:
+//
+//	response := ds.WithTx(ctx, nil, func(tx *pgc.Transaction) error {
+//	    _, err := tx.Tx().ExecContext(ctx, "UPDATE accounts SET balance = balance - $1 WHERE id = $2", 100, 1)
+//	    return err
+//	})
+func (d *Datasource) WithTx(ctx context.Context, opts *TxOptions, fn func(tx *Transaction) error) wrapify.R {
+	if fn == nil {
+		response := wrapify.WrapBadRequest("Transaction function is required", nil).BindCause().Reply()
+		d.dispatchEvent(EventTxAttempt, EventLevelError, response)
+		return response
+	}
+
+	for attempt := 1; ; attempt++ {
+		response, err := d.runTx(ctx, opts, fn)
+		retryable := err != nil && isRetryableTxErr(err) && attempt < defaultTxMaxAttempts
+
+		response = response.
+			WithDebuggingKV("attempt", attempt).
+			WithDebuggingKV("max_attempts", defaultTxMaxAttempts).
+			WithDebuggingKV("retrying", retryable).
+			Reply()
+		switch {
+		case err == nil:
+			d.dispatchEvent(EventTxAttempt, EventLevelSuccess, response)
+			return response
+		case retryable:
+			d.dispatchEvent(EventTxAttempt, EventLevelWarn, response)
+		default:
+			d.dispatchEvent(EventTxAttempt, queryErrLevel(ctx, err), response)
+			return response
+		}
+
+		timer := time.NewTimer(txBackoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			response = wrapQueryErr(ctx, ctx.Err(), "Transaction retry interrupted by context", nil).
+				WithDebuggingKV("attempt", attempt).
+				Reply()
+			d.dispatchEvent(EventTxAttempt, queryErrLevel(ctx, ctx.Err()), response)
+			return response
+		case <-timer.C:
+		}
+	}
+}
+
+// runTx performs a single attempt of WithTx: it begins a transaction, runs fn, and commits or
+// rolls back depending on the outcome. A panic raised by fn triggers a rollback and is then
+// propagated to the caller.
+//
+// Returns:
+//   - The response describing the attempt and the underlying error, if any.
+func (d *Datasource) runTx(ctx context.Context, opts *TxOptions, fn func(tx *Transaction) error) (response wrapify.R, err error) {
+	t, err := d.beginTx(ctx, opts)
+	if err != nil {
+		return t.Wrap(), err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			t.Rollback()
+			panic(r)
+		}
+	}()
+
+	if err = fn(t); err != nil {
+		t.Rollback()
+		response = t.describe(wrapQueryErr(ctx, err, "Transaction function failed, transaction rolled back", nil))
+		return response, err
+	}
+	return t.commit()
+}
+
+// txBackoff returns the delay to wait before the given retry attempt of WithTx.
+// The delay grows exponentially from defaultTxRetryBackoff, is capped at defaultTxRetryMaxBackoff,
+// and includes up to 50% random jitter so that competing transactions do not retry in lockstep.
+func txBackoff(attempt int) time.Duration {
+	delay := defaultTxRetryBackoff << (attempt - 1)
+	if delay <= 0 || delay > defaultTxRetryMaxBackoff {
+		delay = defaultTxRetryMaxBackoff
+	}
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// commit performs the actual commit of the transaction and returns both the response and
+// the raw error reported by the driver, so that callers such as WithTx can inspect the
+// SQLSTATE of a failed commit (e.g. a serialization failure raised at commit time).
+func (t *Transaction) commit() (wrapify.R, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.active || t.tx == nil {
+		response := t.describe(wrapify.WrapBadRequest("Transaction is not active, commit skipped", nil).BindCause().Reply())
+		t.ds.dispatchEvent(EventTxCommit, EventLevelError, response)
+		return response, errTxNotActive
+	}
+	if t.children > 0 {
+		response := t.describe(wrapify.WrapBadRequest(fmt.Sprintf("Transaction has %d open nested transaction(s), commit refused", t.children), nil).
+			WithDebuggingKV("open_children", t.children).
+			BindCause().
+			Reply())
+		t.ds.dispatchEvent(EventTxCommit, EventLevelError, response)
+		return response, errTxOpenChildren
+	}
+	if t.parent != nil {
+		return t.releaseNested()
+	}
+
+	err := t.tx.Commit()
+	t.active = false
+	if err != nil {
+		t.wrap = t.describe(wrapQueryErr(t.context(), err, "Failed to commit transaction", nil))
+		t.ds.dispatchEvent(EventTxCommit, queryErrLevel(t.context(), err), t.wrap)
+		return t.wrap, err
+	}
+
+	t.wrap = t.describe(wrapify.WrapOk("Transaction committed successfully", nil).WithHeader(wrapify.OK).Reply())
+	t.ds.dispatchEvent(EventTxCommit, EventLevelSuccess, t.wrap)
+	return t.wrap, nil
+}
+
+// releaseNested completes a nested transaction successfully by releasing its savepoint.
+// The caller must hold t.mu. The transaction is detached from its parent regardless of
+// the outcome, since a failed RELEASE leaves the savepoint unusable.
+func (t *Transaction) releaseNested() (wrapify.R, error) {
+	query := "RELEASE SAVEPOINT " + pq.QuoteIdentifier(t.savepoint)
+
+	// Start inspection
+	done := t.ds.Inspect("Commit", query)
+	_, err := t.tx.Exec(query)
+	// End inspection
+	done()
+
+	t.active = false
+	t.parent.detach()
+	if err != nil {
+		t.wrap = t.describe(wrapQueryErr(t.context(), err, "Failed to commit nested transaction", nil))
+		t.ds.dispatchEvent(EventTxCommit, queryErrLevel(t.context(), err), t.wrap)
+		return t.wrap, err
+	}
+
+	t.wrap = t.describe(wrapify.WrapOk("Nested transaction committed successfully", nil).WithHeader(wrapify.OK).Reply())
+	t.ds.dispatchEvent(EventTxCommit, EventLevelSuccess, t.wrap)
+	return t.wrap, nil
+}
+
+// rollbackNested aborts a nested transaction by rolling back to its savepoint and then
+// releasing it, leaving the parent transaction usable. The caller must hold t.mu.
+func (t *Transaction) rollbackNested() (wrapify.R, error) {
+	name := pq.QuoteIdentifier(t.savepoint)
+	query := "ROLLBACK TO SAVEPOINT " + name + "; RELEASE SAVEPOINT " + name
+
+	// Start inspection
+	done := t.ds.Inspect("Rollback", query)
+	_, err := t.tx.Exec(query)
+	// End inspection
+	done()
+
+	t.active = false
+	t.parent.detach()
+	if err != nil {
+		t.wrap = t.describe(wrapQueryErr(t.context(), err, "Failed to rollback nested transaction", nil))
+		t.ds.dispatchEvent(EventTxRollback, queryErrLevel(t.context(), err), t.wrap)
+		return t.wrap, err
+	}
+
+	t.wrap = t.describe(wrapify.WrapOk("Nested transaction rolled back successfully", nil).WithHeader(wrapify.OK).Reply())
+	t.ds.dispatchEvent(EventTxRollback, EventLevelSuccess, t.wrap)
+	return t.wrap, nil
+}
+
+// detach decrements the number of open nested transactions once a child has completed.
+func (t *Transaction) detach() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.children > 0 {
+		t.children--
+	}
+}
+
+// context returns the context the transaction was started with, or context.Background() when
+// the transaction was not started.
+func (t *Transaction) context() context.Context {
+	if t.ctx == nil {
+		return context.Background()
+	}
+	return t.ctx
+}
+
+// describe attaches the nesting depth and, for nested transactions, the savepoint name
+// to the response as debugging key-values.
+func (t *Transaction) describe(response wrapify.R) wrapify.R {
+	if t.parent == nil {
+		return response.WithDebuggingKV("depth", t.depth).Reply()
+	}
+	return response.
+		WithDebuggingKV("depth", t.depth).
+		WithDebuggingKV("savepoint", t.savepoint).
+		Reply()
+}
+
+// savepointExec executes a savepoint related statement (SAVEPOINT, ROLLBACK TO SAVEPOINT or
+// RELEASE SAVEPOINT) on the active transaction and dispatches the matching event.
+//
+// Parameters:
+//   - event:    The event key dispatched with the outcome.
+//   - funcName: The name used for query inspection.
+//   - format:   The statement format containing a single %s verb for the quoted savepoint name.
+//   - name:     The savepoint name.
+//   - okMsg:    The message used when the statement succeeds.
+//   - errMsg:   The message used when the statement fails.
+//
+// Returns:
+//   - A wrapify.R instance describing the outcome of the statement.
+func (t *Transaction) savepointExec(event EventKey, funcName, format, name, okMsg, errMsg string) wrapify.R {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if !t.active || t.tx == nil {
+		response := wrapify.WrapBadRequest("Transaction is not active", nil).BindCause().Reply()
+		t.ds.dispatchEvent(event, EventLevelError, response)
+		return response
+	}
+	if isEmpty(name) {
+		response := wrapify.WrapBadRequest("Savepoint name is required", nil).BindCause().Reply()
+		t.ds.dispatchEvent(event, EventLevelError, response)
+		return response
+	}
+
+	query := fmt.Sprintf(format, pq.QuoteIdentifier(name))
+
+	// Start inspection
+	done := t.ds.Inspect(funcName, query)
+	_, err := t.tx.Exec(query)
+	// End inspection
+	done()
+
+	if err != nil {
+		t.wrap = t.describe(wrapQueryErr(t.context(), err, errMsg, nil)).
+			WithDebuggingKV("savepoint", name).
+			Reply()
+		t.ds.dispatchEvent(event, queryErrLevel(t.context(), err), t.wrap)
+		return t.wrap
+	}
+
+	t.wrap = t.describe(wrapify.WrapOk(okMsg, nil).WithHeader(wrapify.OK).Reply()).
+		WithDebuggingKV("savepoint", name).
+		Reply()
+	t.ds.dispatchEvent(event, EventLevelSuccess, t.wrap)
+	return t.wrap
+}
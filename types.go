@@ -1,10 +1,18 @@
 package pgc
 
 import (
+	"container/list"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io/fs"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	"github.com/sivaosorg/wrapify"
 	"gopkg.in/guregu/null.v3"
 )
@@ -30,6 +38,8 @@ import (
 //   - ConnectionStrings: Full connection string example; alternative to specifying individual connection parameters.
 //   - Optional:          Set to true if the connection is optional (won't cause the application to fail if unavailable).
 //   - Schema:            Default database schema to use.
+//   - StmtCacheSize:     Maximum number of prepared statements cached by the Datasource (0 disables the cache).
+//   - CursorSecret:      Secret used to sign pagination cursors (a random per-process key when empty).
 type WConf struct {
 	IsEnabled         bool          `yaml:"enabled"`            // Enables or disables the Postgres connection.
 	IsDebugging       bool          `yaml:"debugging"`          // Turns on/off debugging mode for more verbose logging.
@@ -49,6 +59,8 @@ type WConf struct {
 	ConnectionStrings string        `yaml:"connection_strings"` // Full connection string example; alternative to specifying individual connection parameters.
 	Optional          bool          `yaml:"optional"`           // Set to true if the connection is optional (won't cause the application to fail if unavailable).
 	Schema            string        `yaml:"schema"`             // Default database schema to use.
+	StmtCacheSize     int           `yaml:"stmt_cache_size"`    // Maximum number of prepared statements cached by the Datasource (0 disables the cache).
+	CursorSecret      string        `yaml:"cursor_secret"`      // Secret used to sign pagination cursors (a random per-process key when empty).
 }
 
 // Datasource encapsulates the PostgreSQL connection and its associated configuration,
@@ -97,6 +109,25 @@ type Datasource struct {
 
 	eventPool   *Pool // Worker pool for event callbacks
 	inspectPool *Pool // Worker pool for query inspection
+
+	// stmtCache is the optional LRU cache of prepared statements used by the inspected
+	// execution methods. It is nil when the cache is disabled through settings.
+	stmtCache *stmtCache
+
+	// cursorKey is the HMAC key signing the pagination cursors returned by Paginate.
+	cursorKey []byte
+
+	// queries is the optional registry of named SQL queries executed by ExecByName,
+	// SelectByName and GetByName.
+	queries *QueryRegistry
+
+	// breaker is the optional circuit breaker failing the operations fast while the server
+	// is unreachable. It is nil when no BreakerPolicy is set.
+	breaker *circuitBreaker
+
+	// listener is the LISTEN connection delivering the notifications of the channels
+	// subscribed with Listen. It is created by the first subscription.
+	listener *notifyListener
 }
 
 // SSLModeVarious represents the SSL mode used for connecting to the database.
@@ -143,6 +174,64 @@ type Transaction struct {
 	// are only executed on a valid, ongoing transaction, thus maintaining consistency and preventing
 	// misuse.
 	active bool
+
+	// parent is the enclosing transaction when this transaction is a nested one created through
+	// Begin. It is nil for a top-level transaction started from the Datasource.
+	parent *Transaction
+
+	// depth is the nesting level of the transaction: 0 for a top-level transaction, 1 for its
+	// direct children, and so on.
+	depth int
+
+	// savepoint is the name of the SAVEPOINT backing a nested transaction. It is empty for a
+	// top-level transaction.
+	savepoint string
+
+	// children is the number of nested transactions started from this transaction that have
+	// not yet been committed or rolled back. A transaction refuses to commit while it is non-zero.
+	children int
+
+	// seq is a counter used to generate unique savepoint names for nested transactions.
+	seq int
+
+	// ctx is the context the transaction was started with. Nested transactions inherit the context
+	// of their parent. It is used to report the failures of the transaction statements caused by its
+	// cancellation or deadline (see wrapQueryErr).
+	ctx context.Context
+}
+
+// TxOptions represents the options applied when a transaction is started through BeginTxWith or WithTx.
+//
+// Fields:
+//   - isolation:              The transaction isolation level (e.g. sql.LevelSerializable).
+//   - readOnly:               Indicates whether the transaction is READ ONLY.
+//   - deferrable:             Indicates whether the transaction is DEFERRABLE (only meaningful for SERIALIZABLE READ ONLY).
+//   - statementTimeout:       Per-transaction statement_timeout, issued as SET LOCAL.
+//   - lockTimeout:            Per-transaction lock_timeout, issued as SET LOCAL.
+//   - idleInTxSessionTimeout: Per-transaction idle_in_transaction_session_timeout, issued as SET LOCAL.
+type TxOptions struct {
+	isolation              sql.IsolationLevel
+	readOnly               bool
+	deferrable             bool
+	statementTimeout       time.Duration
+	lockTimeout            time.Duration
+	idleInTxSessionTimeout time.Duration
+}
+
+// UpsertOptions represents the options of an Upsert (INSERT ... ON CONFLICT) statement.
+//
+// Fields:
+//   - constraint: The name of the constraint used as conflict target; when empty, it is resolved from TableKeys.
+//   - include:    The only columns updated on conflict; when empty, every non-key column is updated.
+//   - exclude:    The columns never updated on conflict (e.g. created_at).
+//   - where:      An optional SQL guard appended to DO UPDATE SET as a WHERE clause.
+//   - doNothing:  Indicates whether conflicting rows are left untouched (ON CONFLICT ... DO NOTHING).
+type UpsertOptions struct {
+	constraint string
+	include    []string
+	exclude    []string
+	where      string
+	doNothing  bool
 }
 
 // FuncsSpec represents the metadata for a function parameter retrieved from the PostgreSQL database.
@@ -342,6 +431,41 @@ type QueryInspector interface {
 // QueryInspectorFunc is a function adapter that implements QueryInspector.
 type QueryInspectorFunc func(ins QueryInspect)
 
+// CopySource supplies the rows bulk-loaded by Datasource.CopyFrom.
+//
+// Next returns the next row, or ok=false once the source is exhausted. A row can be a struct
+// (or a pointer to a struct) whose fields are mapped to the copied columns through `db` tags,
+// a map[string]any keyed by column name, a []any holding the values in column order, or a
+// single value when exactly one column is copied.
+//
+// Ready-made sources are provided by CopyFromSlice, CopyFromChan and CopyFromFunc.
+type CopySource interface {
+	Next(ctx context.Context) (row any, ok bool, err error)
+}
+
+// copySliceSource is a CopySource reading rows from a slice.
+type copySliceSource[T any] struct {
+	items []T
+	pos   int
+}
+
+// copyChanSource is a CopySource receiving rows from a channel until it is closed.
+type copyChanSource[T any] struct {
+	ch <-chan T
+}
+
+// copyFuncSource is a CopySource pulling rows from an iterator function.
+type copyFuncSource func(ctx context.Context) (row any, ok bool, err error)
+
+// Executor is the common interface of *Datasource and *Transaction used by the package-level
+// typed query helpers (QueryAll, QueryOne, QueryScalar). It resolves the Datasource whose inspector
+// and event pipeline observe the query, together with the sqlx handle the query runs on.
+//
+// The interface is sealed: it can only be implemented by types of this package.
+type Executor interface {
+	executor() (ds *Datasource, ext sqlx.ExtContext, response wrapify.R, ok bool)
+}
+
 // settings represents the runtime configuration for the PostgreSQL connection.
 //
 // Fields:
@@ -363,6 +487,10 @@ type QueryInspectorFunc func(ins QueryInspect)
 //   - ConnectionStrings: Full connection string example; alternative to specifying individual connection parameters.
 //   - Optional:          Set to true if the connection is optional (won't cause the application to fail if unavailable).
 //   - Schema:            Default database schema to use.
+//   - StmtCacheSize:     Maximum number of prepared statements cached by the Datasource.
+//   - RetryPolicy:       Policy retrying the queries that fail with a transient error.
+//   - BreakerPolicy:     Policy of the circuit breaker failing operations fast while the server is unreachable.
+//   - CursorSecret:      Secret used to sign pagination cursors.
 type settings struct {
 	enabled   bool
 	debugging bool
@@ -436,4 +564,821 @@ type settings struct {
 	// (for example, proceeding without performing database-dependent operations),
 	// whereas a value of false implies that a successful connection is mandatory.
 	optional bool
+
+	// stmtCacheSize is the maximum number of prepared statements kept in the Datasource's
+	// LRU statement cache, keyed by query text. A value of zero (the default) disables the cache,
+	// so that queries are sent to the server without being prepared first. Queries without
+	// arguments are never cached.
+	stmtCacheSize int
+
+	// retryPolicy is the policy retrying the queries that fail with a transient error
+	// (e.g. during a failover). When nil (the default), failed queries are not retried.
+	retryPolicy *RetryPolicy
+
+	// breakerPolicy is the policy of the circuit breaker that fails the operations immediately
+	// while the server is unreachable. When nil (the default), the circuit breaker is disabled.
+	breakerPolicy *BreakerPolicy
+
+	// listenerPoolConf is the configuration of the worker pool running the notification handlers
+	// (see Listen). When nil (the default), 4 workers share a queue of 1024 notifications, and
+	// notifications received while the queue is full are dropped.
+	listenerPoolConf *PoolConf
+
+	// cursorSecret is the secret used to sign the opaque cursors returned by Paginate.
+	// Instances sharing the same secret accept each other's cursors; when empty, a random
+	// key is generated per Datasource, so cursors do not survive a restart.
+	cursorSecret string
+}
+
+// stmtCache is a thread-safe LRU cache of prepared statements keyed by query text.
+//
+// Fields:
+//   - mu:         Guards the list and the index.
+//   - capacity:   The maximum number of cached statements.
+//   - ll:         The recency list; the front holds the most recently used statement.
+//   - items:      The index of list elements by query text.
+//   - hits:       The number of lookups served from the cache.
+//   - misses:     The number of lookups that had to prepare a statement.
+//   - evictions:  The number of statements evicted to honour the capacity.
+//   - reprepares: The number of statements prepared again after their cached plan broke.
+type stmtCache struct {
+	mu         sync.Mutex
+	capacity   int
+	ll         *list.List
+	items      map[string]*list.Element
+	hits       atomic.Uint64
+	misses     atomic.Uint64
+	evictions  atomic.Uint64
+	reprepares atomic.Uint64
+}
+
+// stmtCacheEntry is the value stored in the recency list of a stmtCache.
+//
+// Fields:
+//   - query:   The query text the statement was prepared from.
+//   - db:      The connection the statement was prepared on.
+//   - stmt:    The prepared statement.
+//   - refs:    The number of in-flight calls using the statement.
+//   - evicted: Indicates whether the entry left the cache; the statement is closed once refs drops to zero.
+type stmtCacheEntry struct {
+	query   string
+	db      *sqlx.DB
+	stmt    *sqlx.Stmt
+	refs    int
+	evicted bool
+}
+
+// stmtExt is a sqlx.ExtContext that runs queries through the prepared statements of a stmtCache.
+type stmtExt struct {
+	*sqlx.DB
+	ds    *Datasource
+	cache *stmtCache
+}
+
+// StmtCacheStats holds the counters of the Datasource's prepared statement cache.
+//
+// Fields:
+//   - Capacity:   The maximum number of cached statements (0 when the cache is disabled).
+//   - Size:       The number of statements currently cached.
+//   - Hits:       The number of lookups served from the cache.
+//   - Misses:     The number of lookups that had to prepare a statement.
+//   - Evictions:  The number of statements evicted to honour the capacity.
+//   - Reprepares: The number of statements prepared again after their cached plan broke (SQLSTATE 0A000).
+type StmtCacheStats struct {
+	Capacity   int
+	Size       int
+	Hits       uint64
+	Misses     uint64
+	Evictions  uint64
+	Reprepares uint64
+}
+
+// Cond is a SQL condition used in the WHERE clause of the query builders.
+// Conditions are created with Eq, Ne, Gt, Gte, Lt, Lte, Like, ILike, IsNull, IsNotNull, In, NotIn
+// and Raw, and combined with And, Or and Not.
+//
+// The interface is sealed: it can only be implemented by types of this package.
+type Cond interface {
+	render(b *sqlBuf)
+}
+
+// sqlBuf accumulates the text and the arguments of a statement produced by the query builders,
+// numbering the $n placeholders as arguments are bound. err records the first condition
+// that could not be rendered, which Build reports instead of the statement.
+type sqlBuf struct {
+	strings.Builder
+	args []any
+	err  error
+}
+
+// cmpCond is a binary comparison between a column and a bound value (e.g. "age >= $1").
+type cmpCond struct {
+	column string
+	op     string
+	value  any
+}
+
+// nullCond tests a column against NULL.
+type nullCond struct {
+	column string
+	not    bool
+}
+
+// inCond tests a column against a list of values bound as a single array (= ANY / <> ALL).
+type inCond struct {
+	column string
+	values any
+	not    bool
+}
+
+// groupCond joins conditions with AND or OR, wrapping them in parentheses.
+type groupCond struct {
+	op    string
+	conds []Cond
+}
+
+// notCond negates a condition.
+type notCond struct {
+	cond Cond
+}
+
+// rawCond is a raw SQL fragment using ? placeholders for its arguments.
+type rawCond struct {
+	sql  string
+	args []any
+}
+
+// queryRunner hands a built statement to the inspected execution path.
+// It is embedded by the query builders to provide Exec, Select and Get.
+type queryRunner struct {
+	funcName string
+	build    func() (string, []any, error)
+}
+
+// SelectQuery builds a SELECT statement. Create one with Select.
+type SelectQuery struct {
+	queryRunner
+	columns []string
+	table   string
+	where   []Cond
+	orderBy []string
+	limit   int
+	offset  int
+}
+
+// InsertQuery builds an INSERT statement. Create one with InsertInto.
+type InsertQuery struct {
+	queryRunner
+	table     string
+	columns   []string
+	rows      [][]any
+	returning []string
+}
+
+// UpdateQuery builds an UPDATE statement. Create one with Update.
+type UpdateQuery struct {
+	queryRunner
+	table     string
+	sets      []string
+	values    []any
+	where     []Cond
+	returning []string
+}
+
+// DeleteQuery builds a DELETE statement. Create one with DeleteFrom.
+type DeleteQuery struct {
+	queryRunner
+	table     string
+	where     []Cond
+	returning []string
+}
+
+// SortKey describes one column of a keyset (seek) pagination ordering, together with
+// its direction and the placement of NULL values. Use Asc or Desc to create one.
+type SortKey struct {
+	column     string
+	desc       bool
+	nullsFirst bool
+}
+
+// Page holds one page of rows returned by Paginate.
+//
+// Fields:
+//   - Items:      The rows of the page, in sort order.
+//   - NextCursor: The opaque cursor of the next page; empty on the last page.
+//   - HasMore:    Whether more rows follow this page.
+type Page[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	HasMore    bool   `json:"has_more"`
+}
+
+// pageCursor is the signed payload of a pagination cursor.
+//
+// Fields:
+//   - Order: The rendered ordering the cursor was issued for; a cursor is rejected
+//     when used with a different ordering.
+//   - Keys:  The sort-key values of the last row of the page, in their text
+//     representation (nil for NULL).
+type pageCursor struct {
+	Order string    `json:"o"`
+	Keys  []*string `json:"k"`
+}
+
+// QueryRegistry holds named SQL queries loaded from .sql files, where each query is introduced
+// by a "-- name: <Name>" marker line. It is safe for concurrent use.
+//
+// Fields:
+//   - mu:       Guards the queries and file stamps during a reload.
+//   - fsys:     The file system the queries are loaded from (e.g. an embed.FS or os.DirFS).
+//   - patterns: The glob patterns selecting the files; all .sql files when empty.
+//   - queries:  The loaded queries by name.
+//   - stamps:   The modification time and size of every loaded file, used to detect changes.
+type QueryRegistry struct {
+	mu       sync.RWMutex
+	fsys     fs.FS
+	patterns []string
+	queries  map[string]namedQuery
+	stamps   map[string]fileStamp
+}
+
+// namedQuery is a query of a QueryRegistry together with its location.
+type namedQuery struct {
+	name string
+	file string
+	line int
+	sql  string
+}
+
+// fileStamp identifies a version of a loaded .sql file.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// RoutePolicy selects the replica that serves a read routed through a Cluster.
+type RoutePolicy string
+
+// ClusterOptions represents the options of a Cluster.
+//
+// Fields:
+//   - policy:        How reads are spread over the healthy replicas (round-robin by default).
+//   - maxLag:        The replication lag beyond which a replica is removed from rotation (0 disables the check).
+//   - checkInterval: The interval between two replica health and lag probes.
+type ClusterOptions struct {
+	policy        RoutePolicy
+	maxLag        time.Duration
+	checkInterval time.Duration
+}
+
+// Cluster wraps a primary Datasource and its streaming replicas. Writes and transactions are sent to
+// the primary, while reads are routed to a healthy replica according to the routing policy, falling
+// back to the primary when no replica is available. Each Datasource keeps its own keepalive.
+//
+// Fields:
+//   - primary:  The primary Datasource.
+//   - replicas: The replica Datasources and their probed health.
+//   - opts:     The routing options.
+//   - next:     The round-robin counter.
+//   - stop:     Closed by Close to stop the probing goroutine.
+//   - once:     Guards the closing of stop.
+type Cluster struct {
+	primary  *Datasource
+	replicas []*clusterNode
+	opts     ClusterOptions
+	next     atomic.Uint64
+	stop     chan struct{}
+	once     sync.Once
+}
+
+// clusterNode holds a replica of a Cluster together with the outcome of its last probe.
+//
+// Fields:
+//   - ds:      The replica Datasource.
+//   - healthy: Whether the replica is reachable and within the lag threshold.
+//   - lag:     The last measured replication lag, in nanoseconds.
+//   - latency: The round trip of the last probe, in nanoseconds.
+type clusterNode struct {
+	ds      *Datasource
+	healthy atomic.Bool
+	lag     atomic.Int64
+	latency atomic.Int64
+}
+
+// ReplicaStatus describes the health of a replica of a Cluster as of its last probe.
+//
+// Fields:
+//   - Name:    The display name of the replica, as reported by ConnString.
+//   - Healthy: Whether the replica is in rotation.
+//   - Lag:     The last measured replication lag.
+//   - Latency: The round trip of the last probe.
+type ReplicaStatus struct {
+	Name    string        `json:"name"`
+	Healthy bool          `json:"healthy"`
+	Lag     time.Duration `json:"lag"`
+	Latency time.Duration `json:"latency"`
+}
+
+// Registry holds the named Datasources of a service talking to several PostgreSQL databases,
+// built from a map of WConf. It is safe for concurrent use.
+//
+// Fields:
+//   - mu:       Guards the datasources and the optional flags.
+//   - sources:  The Datasources by name.
+//   - optional: The names of the Datasources whose connection is optional.
+//   - stop:     Closed by Close to stop the background connection retries.
+//   - once:     Guards the closing of stop.
+//   - wg:       Tracks the background connection retries.
+type Registry struct {
+	mu       sync.RWMutex
+	sources  map[string]*Datasource
+	optional map[string]bool
+	stop     chan struct{}
+	once     sync.Once
+	wg       sync.WaitGroup
+}
+
+// DatasourceHealth describes the health of a Datasource of a Registry.
+//
+// Fields:
+//   - Name:      The name of the Datasource in the registry.
+//   - Enabled:   Whether the Datasource is enabled in its configuration.
+//   - Optional:  Whether the connection is optional.
+//   - Connected: Whether the Datasource is currently connected.
+//   - Message:   The message of the current connection state.
+type DatasourceHealth struct {
+	Name      string `json:"name"`
+	Enabled   bool   `json:"enabled"`
+	Optional  bool   `json:"optional"`
+	Connected bool   `json:"connected"`
+	Message   string `json:"message"`
+}
+
+// DatasourcePoolStats gathers the pool statistics of a Datasource of a Registry.
+//
+// Fields:
+//   - Conn:      The statistics of the database connection pool (zero when not connected).
+//   - Event:     The statistics of the event worker pool.
+//   - Inspect:   The statistics of the inspection worker pool.
+//   - StmtCache: The counters of the prepared statement cache.
+type DatasourcePoolStats struct {
+	Conn      sql.DBStats    `json:"conn"`
+	Event     PoolStats      `json:"event"`
+	Inspect   PoolStats      `json:"inspect"`
+	StmtCache StmtCacheStats `json:"stmt_cache"`
+}
+
+// RetryPolicy describes how queries failing with a transient error, such as the connection failures
+// and admin shutdowns observed during a failover, are retried.
+//
+// The policy applies automatically to reads (statements starting with SELECT, SHOW, VALUES, TABLE or
+// EXPLAIN) and to the metadata calls, and to writes only when their context is marked with
+// WithWriteRetry. Statements run within a transaction are never retried, since the transaction
+// does not survive the failure of its connection.
+//
+// Fields:
+//   - maxAttempts: The maximum number of times a query is run, including the first attempt.
+//   - backoff:     The delay before the first retry; it doubles after each retry.
+//   - maxBackoff:  The upper bound of the delay between two attempts.
+//   - jitter:      The fraction (0 to 1) of each delay that is randomized to spread retries over time.
+//   - classifier:  Reports whether an error is transient and worth retrying (IsTransientErr by default).
+type RetryPolicy struct {
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	jitter      float64
+	classifier  func(err error) bool
+}
+
+// retryDB wraps the connection pool of a Datasource so that the queries of the metadata calls
+// are retried according to the retry policy.
+//
+// Fields:
+//   - DB:       The underlying connection pool.
+//   - ds:       The Datasource owning the retry policy and receiving the retry events.
+//   - funcName: The name of the calling function, reported in the retry events.
+type retryDB struct {
+	*sqlx.DB
+	ds       *Datasource
+	funcName string
+}
+
+// Notification is a notification received on a channel the Datasource listens to.
+//
+// Fields:
+//   - Channel: The channel the notification was sent on.
+//   - Payload: The payload of the notification (empty when none was given).
+//   - PID:     The process ID of the server backend that sent the notification.
+type Notification struct {
+	Channel string `json:"channel"`
+	Payload string `json:"payload"`
+	PID     int    `json:"pid"`
+}
+
+// NotificationHandler handles the notifications received on a channel subscribed with Listen.
+type NotificationHandler func(n Notification)
+
+// notifyListener holds the dedicated LISTEN connection of a Datasource and the handlers
+// of its subscribed channels.
+//
+// Fields:
+//   - mu:       Guards the handlers.
+//   - conn:     The pq listener connection, reconnecting on its own after a failure.
+//   - handlers: The handler of every subscribed channel.
+//   - pool:     The worker pool running the handlers, so that a slow handler cannot block the listener.
+//   - stop:     Closed to stop the delivery loop.
+//   - done:     Closed once the delivery loop has returned.
+type notifyListener struct {
+	mu       sync.RWMutex
+	conn     *pq.Listener
+	handlers map[string]NotificationHandler
+	pool     *Pool
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// ChangeOp is the operation that changed a row of a table watched with WatchTable.
+type ChangeOp string
+
+// RowChange is a change to a row of a table watched with WatchTable.
+//
+// Fields:
+//   - Op:        The operation (ChangeOpInsert, ChangeOpUpdate or ChangeOpDelete).
+//   - Schema:    The schema of the table.
+//   - Table:     The name of the table.
+//   - Key:       The primary key of the row, by column name. Numbers are decoded as json.Number.
+//   - Old:       The row before an update or a delete, as a JSON object.
+//   - New:       The row after an insert or an update, as a JSON object.
+//   - Truncated: Reports that Old and New were left out because the change exceeded the NOTIFY payload limit;
+//     the row can be read back using Key.
+type RowChange struct {
+	Op        ChangeOp        `json:"op"`
+	Schema    string          `json:"schema"`
+	Table     string          `json:"table"`
+	Key       map[string]any  `json:"key"`
+	Old       json.RawMessage `json:"old,omitempty"`
+	New       json.RawMessage `json:"new,omitempty"`
+	Truncated bool            `json:"truncated,omitempty"`
+}
+
+// CDCPlugin names the logical decoding output plugin of the replication slot read by a CDCReader.
+type CDCPlugin string
+
+// CDCOptions represents the options of a CDCReader.
+//
+// Fields:
+//   - plugin:       The output plugin of the replication slot (CDCPluginTestDecoding by default).
+//   - batchSize:    The number of changes peeked per poll; whole transactions are always included.
+//   - pollInterval: The delay between two polls that found no pending change.
+//   - lagThreshold: The slot lag, in bytes of WAL, beyond which EventCDCSlotLag is reported as a warning.
+type CDCOptions struct {
+	plugin       CDCPlugin
+	batchSize    int
+	pollInterval time.Duration
+	lagThreshold int64
+}
+
+// CDCReader consumes the changes decoded by a logical replication slot through the SQL slot functions.
+// Changes are peeked in batches, handed over to a handler, and acknowledged (consumed from the slot)
+// only once the handler succeeds, so that a failed batch is delivered again by the next poll.
+//
+// Fields:
+//   - ds:   The Datasource the slot belongs to.
+//   - slot: The name of the replication slot.
+//   - opts: The reader options.
+type CDCReader struct {
+	ds   *Datasource
+	slot string
+	opts CDCOptions
+}
+
+// CDCRecord is a row change decoded from a logical replication slot.
+//
+// Fields:
+//   - LSN:    The log sequence number of the change.
+//   - XID:    The ID of the transaction that made the change.
+//   - Op:     The operation (ChangeOpInsert, ChangeOpUpdate or ChangeOpDelete).
+//   - Schema: The schema of the table.
+//   - Table:  The name of the table.
+//   - Old:    The replica identity columns of the row before an update or a delete, by column name
+//     (nil when the table's replica identity does not log them).
+//   - New:    The columns of the row after an insert or an update, by column name. Unchanged TOASTed
+//     values are left out.
+type CDCRecord struct {
+	LSN    string         `json:"lsn"`
+	XID    int64          `json:"xid"`
+	Op     ChangeOp       `json:"op"`
+	Schema string         `json:"schema"`
+	Table  string         `json:"table"`
+	Old    map[string]any `json:"old,omitempty"`
+	New    map[string]any `json:"new,omitempty"`
+}
+
+// cdcChange is a row returned by pg_logical_slot_peek_changes.
+type cdcChange struct {
+	LSN  string `db:"lsn"`
+	XID  int64  `db:"xid"`
+	Data string `db:"data"`
+}
+
+// wal2jsonChange is a change printed by the wal2json output plugin (format version 2).
+type wal2jsonChange struct {
+	Action   string           `json:"action"`
+	Schema   string           `json:"schema"`
+	Table    string           `json:"table"`
+	Columns  []wal2jsonColumn `json:"columns"`
+	Identity []wal2jsonColumn `json:"identity"`
+}
+
+// wal2jsonColumn is a column value printed by the wal2json output plugin.
+type wal2jsonColumn struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+// OutboxStatus is the state of a message of the outbox table.
+type OutboxStatus string
+
+// OutboxMessage is a message of the outbox table, enqueued by Transaction.Enqueue.
+//
+// Fields:
+//   - ID:        The sequential ID of the message.
+//   - Topic:     The topic the message is published to.
+//   - Payload:   The JSON payload of the message.
+//   - Attempts:  The number of publish attempts already made.
+//   - CreatedAt: The time the message was enqueued.
+type OutboxMessage struct {
+	ID        int64           `json:"id"`
+	Topic     string          `json:"topic"`
+	Payload   json.RawMessage `json:"payload"`
+	Attempts  int             `json:"attempts"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// outboxRow is a claimed row of the outbox table. The payload is scanned into a []byte,
+// which database/sql copies, rather than into a json.RawMessage, which would alias the driver's buffer.
+type outboxRow struct {
+	ID        int64     `db:"id"`
+	Topic     string    `db:"topic"`
+	Payload   []byte    `db:"payload"`
+	Attempts  int       `db:"attempts"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// deadLetter is an outbox message dead-lettered by a dispatch, reported once the dispatch has committed.
+//
+// Fields:
+//   - msg:      The dead-lettered message.
+//   - attempts: The number of publish attempts of the message.
+//   - err:      The error of the last attempt.
+type deadLetter struct {
+	msg      OutboxMessage
+	attempts int
+	err      error
+}
+
+// OutboxPublisher publishes an outbox message, typically to a message broker.
+// A nil error marks the message as done; any other error schedules a retry.
+type OutboxPublisher func(ctx context.Context, msg OutboxMessage) error
+
+// OutboxOptions represents the options of an OutboxDispatcher.
+//
+// Fields:
+//   - batchSize:    The number of messages claimed per dispatch.
+//   - pollInterval: The delay between two dispatches that found no pending message.
+//   - maxAttempts:  The number of publish attempts after which a message is dead-lettered.
+//   - backoff:      The delay before the first retry of a failed message; it doubles after each attempt.
+//   - maxBackoff:   The upper bound of the delay between two publish attempts of a message.
+//   - workers:      The number of messages of a batch published concurrently.
+type OutboxOptions struct {
+	batchSize    int
+	pollInterval time.Duration
+	maxAttempts  int
+	backoff      time.Duration
+	maxBackoff   time.Duration
+	workers      int
+}
+
+// OutboxDispatcher publishes the messages of the outbox table in the background.
+//
+// Fields:
+//   - ds:        The Datasource owning the outbox table.
+//   - publisher: The function publishing a message.
+//   - opts:      The dispatcher options.
+//   - pool:      The worker pool publishing the messages of a batch concurrently.
+//   - mu:        Guards the lifecycle fields below.
+//   - cancel:    Cancels the context of the dispatch loop.
+//   - done:      Closed once the dispatch loop has returned.
+type OutboxDispatcher struct {
+	ds        *Datasource
+	publisher OutboxPublisher
+	opts      OutboxOptions
+	pool      *Pool
+	mu        sync.Mutex
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+// JobStatus is the state of a job of the job queue.
+type JobStatus string
+
+// JobOptions represents the options of a job enqueued with EnqueueJob.
+//
+// Fields:
+//   - runAt:       The earliest time the job may run (now when zero).
+//   - priority:    The priority of the job; jobs with a higher priority are claimed first.
+//   - maxAttempts: The number of attempts after which the job is marked as failed.
+//   - uniqueKey:   A key identifying the job within its queue: while a pending or running job has the
+//     same key, enqueuing another one returns the existing job instead.
+type JobOptions struct {
+	runAt       time.Time
+	priority    int
+	maxAttempts int
+	uniqueKey   string
+}
+
+// QueuedJob is a job of the job queue, handed over to the JobHandler of a JobWorker.
+//
+// Fields:
+//   - ID:          The sequential ID of the job.
+//   - Queue:       The queue the job belongs to.
+//   - Payload:     The JSON payload of the job.
+//   - Priority:    The priority of the job.
+//   - Attempt:     The number of the current attempt, starting at 1.
+//   - MaxAttempts: The number of attempts after which the job is marked as failed.
+//   - UniqueKey:   The uniqueness key of the job, if any.
+//   - RunAt:       The time the job was scheduled to run.
+//   - CreatedAt:   The time the job was enqueued.
+type QueuedJob struct {
+	ID          int64           `json:"id"`
+	Queue       string          `json:"queue"`
+	Payload     json.RawMessage `json:"payload"`
+	Priority    int             `json:"priority"`
+	Attempt     int             `json:"attempt"`
+	MaxAttempts int             `json:"max_attempts"`
+	UniqueKey   string          `json:"unique_key,omitempty"`
+	RunAt       time.Time       `json:"run_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// jobRow is a claimed row of the job queue table, with the delay in seconds between its scheduled
+// run time and its claim; see outboxRow for why the payload is a []byte.
+type jobRow struct {
+	ID          int64     `db:"id"`
+	Queue       string    `db:"queue"`
+	Payload     []byte    `db:"payload"`
+	Priority    int       `db:"priority"`
+	Attempts    int       `db:"attempts"`
+	MaxAttempts int       `db:"max_attempts"`
+	UniqueKey   string    `db:"unique_key"`
+	RunAt       time.Time `db:"run_at"`
+	CreatedAt   time.Time `db:"created_at"`
+	Latency     float64   `db:"latency"`
+}
+
+// JobHandler runs a job. A nil error completes the job; any other error schedules a retry
+// with an exponential backoff, or marks the job as failed after its last attempt.
+type JobHandler func(ctx context.Context, job QueuedJob) error
+
+// JobWorkerOptions represents the options of a JobWorker.
+//
+// Fields:
+//   - concurrency:       The number of jobs run concurrently.
+//   - pollInterval:      The delay between two claims that found no pending job.
+//   - heartbeatInterval: The interval between two heartbeats of the running jobs.
+//   - reclaimAfter:      How long a running job may go without heartbeat before it is reclaimed.
+//   - backoff:           The delay before the first retry of a failed job; it doubles after each attempt.
+//   - maxBackoff:        The upper bound of the delay between two attempts of a job.
+type JobWorkerOptions struct {
+	concurrency       int
+	pollInterval      time.Duration
+	heartbeatInterval time.Duration
+	reclaimAfter      time.Duration
+	backoff           time.Duration
+	maxBackoff        time.Duration
+}
+
+// JobStats holds the runtime statistics of a JobWorker.
+//
+// Fields:
+//   - Claimed:     Total number of jobs claimed.
+//   - Completed:   Total number of jobs completed successfully.
+//   - Retried:     Total number of failed attempts scheduled for a retry.
+//   - Failed:      Total number of jobs that failed their last attempt.
+//   - Reclaimed:   Total number of stale jobs of the queue reclaimed by this worker.
+//   - Running:     Current number of jobs run by this worker.
+//   - Depth:       Number of jobs of the queue ready to run, as of the last heartbeat.
+//   - LastLatency: Delay between the scheduled run time and the claim of the last claimed job.
+//   - AvgLatency:  Average delay between the scheduled run time and the claim of the claimed jobs.
+type JobStats struct {
+	Claimed     uint64        `json:"claimed"`
+	Completed   uint64        `json:"completed"`
+	Retried     uint64        `json:"retried"`
+	Failed      uint64        `json:"failed"`
+	Reclaimed   uint64        `json:"reclaimed"`
+	Running     int           `json:"running"`
+	Depth       int64         `json:"depth"`
+	LastLatency time.Duration `json:"last_latency"`
+	AvgLatency  time.Duration `json:"avg_latency"`
+}
+
+// JobWorker runs the jobs of a queue on a worker pool.
+//
+// Fields:
+//   - ds:           The Datasource owning the job queue table.
+//   - queue:        The queue the worker claims jobs from.
+//   - handler:      The function running a job.
+//   - opts:         The worker options.
+//   - id:           The identifier recorded on the jobs claimed by the worker.
+//   - pool:         The worker pool running the jobs.
+//   - mu:           Guards the lifecycle fields.
+//   - runMu:        Guards the running jobs.
+//   - running:      The jobs being run, by ID, with the attempt of their claim.
+//   - freed:        Signals the claim loop that a job has finished.
+//   - cancel:       Stops the claim loop.
+//   - cancelJobs:   Cancels the context of the jobs still running after Stop's grace period.
+//   - done:         Closed once the claim loop has returned.
+//   - claimed, completed, retried, failed, reclaimed, depth, lastLatency, totalLatency: The statistics counters.
+type JobWorker struct {
+	ds           *Datasource
+	queue        string
+	handler      JobHandler
+	opts         JobWorkerOptions
+	id           string
+	pool         *Pool
+	mu           sync.Mutex
+	runMu        sync.Mutex
+	running      map[int64]int
+	freed        chan struct{}
+	cancel       context.CancelFunc
+	cancelJobs   context.CancelFunc
+	done         chan struct{}
+	claimed      atomic.Uint64
+	completed    atomic.Uint64
+	retried      atomic.Uint64
+	failed       atomic.Uint64
+	reclaimed    atomic.Uint64
+	depth        atomic.Int64
+	lastLatency  atomic.Int64
+	totalLatency atomic.Int64
+}
+
+// ErrCategory is the category of an error reported by PostgreSQL, deciding the status code
+// of the response describing it.
+type ErrCategory string
+
+// BreakerState is the state of the circuit breaker of a Datasource.
+type BreakerState string
+
+// BreakerPolicy describes when the circuit breaker of a Datasource opens, failing every operation
+// immediately instead of waiting for the connection timeout, and when it closes again.
+//
+// The breaker opens when consecutive failures reach the failure threshold, or when the failure rate
+// over the current window reaches the error rate once the window counts at least minRequests operations.
+// After the open timeout it turns half-open and lets a limited number of probe operations through: the
+// first probe to succeed closes it, the first to fail opens it again. A successful keepalive ping closes
+// it early. Only the errors reported by the classifier count as failures, so that constraint violations
+// and other query errors answered by a healthy server do not open the breaker.
+//
+// Fields:
+//   - failureThreshold: The number of consecutive failures opening the breaker (0 disables the check).
+//   - errorRate:        The failure rate (0 to 1) over the window opening the breaker (0 disables the check).
+//   - minRequests:      The minimum number of operations in the window before its failure rate is evaluated.
+//   - window:           The length of the window over which the failure rate is measured.
+//   - openTimeout:      How long the breaker stays open before turning half-open.
+//   - halfOpenProbes:   The number of probe operations let through while the breaker is half-open.
+//   - classifier:       Reports whether an error counts as a failure (IsTransientErr by default).
+type BreakerPolicy struct {
+	failureThreshold int
+	errorRate        float64
+	minRequests      int
+	window           time.Duration
+	openTimeout      time.Duration
+	halfOpenProbes   int
+	classifier       func(err error) bool
+}
+
+// circuitBreaker holds the state of the circuit breaker of a Datasource.
+//
+// Fields:
+//   - mu:          Guards the fields below.
+//   - policy:      The policy driving the state transitions.
+//   - state:       The current state.
+//   - consecutive: The number of consecutive failures while closed.
+//   - requests:    The number of operations recorded in the current window.
+//   - failures:    The number of failures recorded in the current window.
+//   - windowStart: The start of the current window.
+//   - openedAt:    The time the breaker last opened.
+//   - probes:      The number of probe operations let through since the breaker turned half-open.
+type circuitBreaker struct {
+	mu          sync.Mutex
+	policy      *BreakerPolicy
+	state       BreakerState
+	consecutive int
+	requests    int
+	failures    int
+	windowStart time.Time
+	openedAt    time.Time
+	probes      int
 }
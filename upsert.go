@@ -0,0 +1,225 @@
+package pgc
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sivaosorg/wrapify"
+)
+
+// Upsert inserts rows into the given table, updating the existing rows that conflict with them
+// (INSERT ... ON CONFLICT ON CONSTRAINT ... DO UPDATE SET ...).
+//
+// Columns are derived from the `db` tags of the struct fields, exactly as for BatchInsert. Unless a
+// constraint is given explicitly through UpsertOptions.SetConstraint, the conflict target is resolved
+// from the metadata returned by TableKeys: the primary key is used when its columns are all part of the
+// inserted columns, otherwise the single unique constraint whose columns are. When several unique
+// constraints qualify, a bad request response asks for an explicit constraint.
+//
+// On conflict, every inserted column that is not part of the conflict target is updated from the
+// incoming row (EXCLUDED). The updated columns can be narrowed with SetInclude and SetExclude, guarded
+// with SetWhere (e.g. "users.version < EXCLUDED.version"), or replaced by DO NOTHING with SetDoNothing.
+//
+// Like BatchInsert, rows are chunked to respect PostgreSQL's bind-parameter limit and a multi-chunk
+// upsert on the Datasource runs inside a single transaction. Note that PostgreSQL rejects a statement
+// that updates the same row twice, so rows of a single call must not share a conflict key.
+//
+// Parameters:
+//   - ctx:   The context controlling cancellation and deadlines.
+//   - table: The name of the target table, optionally schema-qualified.
+//   - rows:  A struct, or a slice of structs or pointers to structs.
+//   - opts:  The upsert options (nil applies the defaults).
+//
+// Returns:
+//   - A wrapify.R instance describing the outcome, with the number of inserted or updated rows as total.
+//
+// Example:
+//
+//	opts := pgc.NewUpsertOptions().
+//	    SetExclude("created_at").
+//	    SetWhere("products.version < EXCLUDED.version")
+//	response := ds.Upsert(ctx, "products", products, opts)
+func (d *Datasource) Upsert(ctx context.Context, table string, rows any, opts *UpsertOptions) wrapify.R {
+	return upsert(ctx, d, table, rows, opts)
+}
+
+// Upsert inserts or updates rows within the transaction.
+// See Datasource.Upsert for details on conflict target resolution and options.
+func (t *Transaction) Upsert(ctx context.Context, table string, rows any, opts *UpsertOptions) wrapify.R {
+	return upsert(ctx, t, table, rows, opts)
+}
+
+// upsert builds the ON CONFLICT clause for the given rows and writes them through insertRows.
+func upsert(ctx context.Context, exec Executor, table string, rows any, opts *UpsertOptions) wrapify.R {
+	ds, _, response, ok := exec.executor()
+	if !ok {
+		return response
+	}
+	if isEmpty(table) {
+		response := wrapify.WrapBadRequest("Table name is required", nil).BindCause()
+		ds.dispatchEvent(EventUpsert, EventLevelError, response.Reply())
+		return response.Reply()
+	}
+	columns, values, err := batchValues(ds.Conn().Mapper, rows)
+	if err != nil {
+		response := wrapify.WrapBadRequest(err.Error(), nil).WithErrSck(err)
+		ds.dispatchEvent(EventUpsert, EventLevelError, response.Reply())
+		return response.Reply()
+	}
+
+	constraint, keys, response := ds.conflictTarget(ctx, table, columns, opts.Constraint())
+	if response.IsError() {
+		ds.dispatchEvent(EventUpsert, EventLevelError, response)
+		return response
+	}
+	conflict, response := upsertClause(table, columns, constraint, keys, opts)
+	if response.IsError() {
+		ds.dispatchEvent(EventUpsert, EventLevelError, response)
+		return response
+	}
+
+	_, response = insertRows[struct{}](ctx, exec, "Upsert", EventUpsert, table, columns, values, conflict, nil)
+	return response
+}
+
+// conflictTarget returns the constraint used as conflict target together with its columns.
+//
+// When constraint is empty, the primary key reported by TableKeysCtx for the quoted table name is chosen
+// if its columns are all present in columns. Otherwise the unique constraints whose columns are all present in columns are
+// considered: a single one is chosen, while several are ambiguous and rejected with a bad request
+// response, so that the conflict target does not depend on the order of the catalog rows.
+func (d *Datasource) conflictTarget(ctx context.Context, table string, columns []string, constraint string) (string, []string, wrapify.R) {
+	if isNotEmpty(constraint) {
+		keys, response := d.constraintCols(ctx, table, constraint)
+		return constraint, keys, response
+	}
+
+	defs, response := d.TableKeysCtx(ctx, quoteIdent(table))
+	if response.IsError() {
+		return "", nil, response
+	}
+	var primary, uniques []string
+	for _, def := range defs {
+		switch def.Type {
+		case "Primary Key":
+			primary = append(primary, def.Name)
+		case "Unique Key":
+			uniques = append(uniques, def.Name)
+		}
+	}
+	slices.Sort(uniques)
+	for _, candidate := range primary {
+		keys, response := d.constraintCols(ctx, table, candidate)
+		if response.IsError() {
+			return "", nil, response
+		}
+		if containsAll(columns, keys) {
+			return candidate, keys, response
+		}
+	}
+
+	var covered []string
+	var coveredKeys [][]string
+	for _, candidate := range uniques {
+		keys, response := d.constraintCols(ctx, table, candidate)
+		if response.IsError() {
+			return "", nil, response
+		}
+		if containsAll(columns, keys) {
+			covered = append(covered, candidate)
+			coveredKeys = append(coveredKeys, keys)
+		}
+	}
+	switch len(covered) {
+	case 0:
+		response = wrapify.WrapBadRequest(fmt.Sprintf("No primary key or unique constraint of table '%s' is covered by the columns %v", table, columns), nil).
+			WithDebuggingKV("constraints", append(primary, uniques...)).
+			BindCause().
+			Reply()
+		return "", nil, response
+	case 1:
+		return covered[0], coveredKeys[0], wrapify.WrapOk(fmt.Sprintf("Constraint '%s' chosen as conflict target", covered[0]), nil).Reply()
+	default:
+		response = wrapify.WrapBadRequest(fmt.Sprintf("Several unique constraints of table '%s' are covered by the columns %v, set the conflict target explicitly (UpsertOptions.SetConstraint)", table, columns), nil).
+			WithDebuggingKV("constraints", covered).
+			BindCause().
+			Reply()
+		return "", nil, response
+	}
+}
+
+// constraintCols returns the columns of the named constraint of table, in constraint order.
+// The table is resolved through regclass from its quoted name, the way the generated statements
+// refer to it, so that a mixed-case or reserved name designates the same relation.
+func (d *Datasource) constraintCols(ctx context.Context, table, constraint string) (cols []string, response wrapify.R) {
+	query := `
+		SELECT a.attname
+		FROM pg_constraint c
+		CROSS JOIN LATERAL unnest(c.conkey) WITH ORDINALITY AS k(attnum, ord)
+		JOIN pg_attribute a ON a.attrelid = c.conrelid AND a.attnum = k.attnum
+		WHERE c.conrelid = regclass($1)
+		AND c.conname = $2
+		ORDER BY k.ord;
+	`
+	relation := quoteIdent(table)
+	// Start inspection
+	done := d.Inspect("Upsert-constraint", query, relation, constraint)
+	err := d.reader("Upsert-constraint").SelectContext(ctx, &cols, query, relation, constraint)
+	// End inspection
+	done()
+
+	if err != nil {
+		return cols, wrapQueryErr(ctx, err, fmt.Sprintf("An error occurred while retrieving the columns of constraint '%s' on table '%s'", constraint, table), nil)
+	}
+	if len(cols) == 0 {
+		return cols, wrapify.WrapNotFound(fmt.Sprintf("Constraint '%s' not found on table '%s'", constraint, table), nil).BindCause().Reply()
+	}
+	return cols, wrapify.WrapOk(fmt.Sprintf("Retrieved the columns of constraint '%s' successfully", constraint), cols).WithTotal(len(cols)).Reply()
+}
+
+// upsertClause renders the ON CONFLICT clause: DO NOTHING when requested or when no column is left
+// to update, DO UPDATE SET column = EXCLUDED.column otherwise, followed by the optional WHERE guard.
+func upsertClause(table string, columns []string, constraint string, keys []string, opts *UpsertOptions) (string, wrapify.R) {
+	include := opts.Include()
+	if unknown := missingFrom(columns, include); len(unknown) > 0 {
+		response := wrapify.WrapBadRequest(fmt.Sprintf("Included columns %v are not inserted into table '%s'", unknown, table), nil).
+			BindCause().
+			Reply()
+		return "", response
+	}
+
+	var updates []string
+	for _, column := range columns {
+		if slices.Contains(keys, column) || slices.Contains(opts.Exclude(), column) {
+			continue
+		}
+		if len(include) > 0 && !slices.Contains(include, column) {
+			continue
+		}
+		updates = append(updates, column)
+	}
+
+	var b strings.Builder
+	b.WriteString("ON CONFLICT ON CONSTRAINT ")
+	b.WriteString(quoteIdent(constraint))
+	if opts.IsDoNothing() || len(updates) == 0 {
+		b.WriteString(" DO NOTHING")
+		return b.String(), wrapify.WrapOk("Built conflict clause", nil).Reply()
+	}
+	b.WriteString(" DO UPDATE SET ")
+	for i, column := range updates {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(quoteIdent(column))
+		b.WriteString(" = EXCLUDED.")
+		b.WriteString(quoteIdent(column))
+	}
+	if where := opts.Where(); isNotEmpty(where) {
+		b.WriteString(" WHERE ")
+		b.WriteString(where)
+	}
+	return b.String(), wrapify.WrapOk("Built conflict clause", nil).Reply()
+}
@@ -0,0 +1,240 @@
+package pgc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/sivaosorg/wrapify"
+)
+
+// watchFuncDDL creates or replaces the trigger function shared by the watched tables. Its first
+// argument is the notification channel and the following ones the primary key columns. When the
+// full change does not fit in a NOTIFY payload, only the key is sent and the change is flagged as truncated.
+const watchFuncDDL = `
+	CREATE OR REPLACE FUNCTION ` + watchFuncName + `() RETURNS trigger AS $pgc$
+	DECLARE
+		row_json jsonb;
+		old_json jsonb;
+		new_json jsonb;
+		key_json jsonb := '{}'::jsonb;
+		payload text;
+	BEGIN
+		IF TG_OP IN ('UPDATE', 'DELETE') THEN
+			old_json := to_jsonb(OLD);
+		END IF;
+		IF TG_OP IN ('INSERT', 'UPDATE') THEN
+			new_json := to_jsonb(NEW);
+		END IF;
+		row_json := COALESCE(new_json, old_json);
+		FOR i IN 1 .. TG_NARGS - 1 LOOP
+			key_json := key_json || jsonb_build_object(TG_ARGV[i], row_json -> TG_ARGV[i]);
+		END LOOP;
+		payload := jsonb_build_object('op', TG_OP, 'schema', TG_TABLE_SCHEMA, 'table', TG_TABLE_NAME,
+			'key', key_json, 'old', old_json, 'new', new_json)::text;
+		IF octet_length(payload) >= 8000 THEN
+			payload := jsonb_build_object('op', TG_OP, 'schema', TG_TABLE_SCHEMA, 'table', TG_TABLE_NAME,
+				'key', key_json, 'truncated', true)::text;
+		END IF;
+		PERFORM pg_notify(TG_ARGV[0], payload);
+		RETURN NULL;
+	END;
+	$pgc$ LANGUAGE plpgsql;
+`
+
+// WatchTable streams the changes made to the rows of table, by any session, to handler.
+//
+// It installs an AFTER INSERT OR UPDATE OR DELETE row trigger on the table, calling a trigger function
+// that sends every change as a JSON notification (see Listen): the operation, the primary key reported
+// by TableKeys, and the old and new rows. A change exceeding the 8000-byte NOTIFY payload limit is sent
+// with its key only and flagged as Truncated. The installation is idempotent: watching a table again,
+// from this or another process, replaces the trigger and the handler. Changes are only sent once the
+// transaction that made them commits; changes made while the listener is disconnected are lost.
+//
+// Parameters:
+//   - ctx:     The context used to install the trigger.
+//   - table:   The table to watch, optionally schema-qualified. It must have a primary key.
+//   - handler: The function handling the changes.
+//
+// Returns:
+//   - A wrapify.R instance describing the outcome.
+//
+// Example:
+//
+//	response := ds.WatchTable(ctx, "public.orders", func(change pgc.RowChange) {
+//	    var order Order
+//	    if change.Op != pgc.ChangeOpDelete && !change.Truncated {
+//	        _ = change.DecodeNew(&order)
+//	    }
+//	    log.Printf("%s order %v", change.Op, change.Key["id"])
+//	})
+//	defer ds.UnwatchTable(context.Background(), "public.orders")
+func (d *Datasource) WatchTable(ctx context.Context, table string, handler func(change RowChange)) wrapify.R {
+	if !d.IsConnected() {
+		return d.State()
+	}
+	if isEmpty(table) || handler == nil {
+		response := wrapify.WrapBadRequest("Table name and handler are required to watch a table", nil).BindCause().Reply()
+		d.dispatchEvent(EventTableWatch, EventLevelError, response)
+		return response
+	}
+
+	keys, response := d.primaryKeyCols(ctx, table)
+	if response.IsError() {
+		d.dispatchEvent(EventTableWatch, queryErrLevel(ctx, response.Cause()), response)
+		return response
+	}
+
+	channel := watchChannel(table)
+	response = d.Listen(channel, func(n Notification) {
+		var change RowChange
+		decoder := json.NewDecoder(strings.NewReader(n.Payload))
+		decoder.UseNumber()
+		if err := decoder.Decode(&change); err != nil {
+			response := wrapify.WrapBadRequest(fmt.Sprintf("Failed to decode a change of table '%s'", table), n).
+				WithDebuggingKV("channel", n.Channel).
+				WithErrSck(err).
+				Reply()
+			d.dispatchEvent(EventTableWatch, EventLevelError, response)
+			return
+		}
+		handler(change)
+	})
+	if response.IsError() {
+		return response
+	}
+
+	args := make([]string, 0, len(keys)+1)
+	for _, value := range append([]string{channel}, keys...) {
+		args = append(args, pq.QuoteLiteral(value))
+	}
+	query := fmt.Sprintf(`
+		SELECT pg_advisory_xact_lock(hashtext('%[1]s'));
+		%[2]s
+		DROP TRIGGER IF EXISTS %[3]s ON %[4]s;
+		CREATE TRIGGER %[3]s AFTER INSERT OR UPDATE OR DELETE ON %[4]s
+		FOR EACH ROW EXECUTE PROCEDURE %[1]s(%[5]s);
+	`, watchFuncName, watchFuncDDL, watchTriggerName, quoteIdent(table), strings.Join(args, ", "))
+	if err := d.execScript(ctx, "WatchTable", query); err != nil {
+		d.Unlisten(channel)
+		response := wrapQueryErr(ctx, err, fmt.Sprintf("An error occurred while installing the watch trigger on table '%s'", table), nil)
+		d.dispatchEvent(EventTableWatch, queryErrLevel(ctx, err), response.Reply())
+		return response.Reply()
+	}
+
+	response = wrapify.WrapOk(fmt.Sprintf("Watching the changes of table '%s'", table), nil).
+		WithDebuggingKV("channel", channel).
+		WithDebuggingKV("keys", keys).
+		Reply()
+	d.dispatchEvent(EventTableWatch, EventLevelSuccess, response)
+	return response
+}
+
+// UnwatchTable stops watching table: it drops the trigger installed by WatchTable and stops listening
+// to the notifications of the table. The trigger function, shared by every watched table, is kept.
+// Since the trigger is removed for every process, other processes watching the same table stop
+// receiving its changes as well.
+//
+// Parameters:
+//   - ctx:   The context used to drop the trigger.
+//   - table: The watched table, as given to WatchTable.
+//
+// Returns:
+//   - A wrapify.R instance describing the outcome.
+func (d *Datasource) UnwatchTable(ctx context.Context, table string) wrapify.R {
+	if !d.IsConnected() {
+		return d.State()
+	}
+	if isEmpty(table) {
+		response := wrapify.WrapBadRequest("Table name is required to unwatch a table", nil).BindCause().Reply()
+		d.dispatchEvent(EventTableUnwatch, EventLevelError, response)
+		return response
+	}
+
+	query := fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s;", watchTriggerName, quoteIdent(table))
+	if err := d.execScript(ctx, "UnwatchTable", query); err != nil {
+		response := wrapQueryErr(ctx, err, fmt.Sprintf("An error occurred while removing the watch trigger from table '%s'", table), nil)
+		d.dispatchEvent(EventTableUnwatch, queryErrLevel(ctx, err), response.Reply())
+		return response.Reply()
+	}
+	d.Unlisten(watchChannel(table))
+
+	response := wrapify.WrapOk(fmt.Sprintf("Stopped watching the changes of table '%s'", table), nil).Reply()
+	d.dispatchEvent(EventTableUnwatch, EventLevelSuccess, response)
+	return response
+}
+
+// DecodeOld decodes the row before the change into dest.
+// It returns an error for an insert or a truncated change, which carry no old row.
+func (c RowChange) DecodeOld(dest any) error {
+	return decodeRow(c.Old, "old", dest)
+}
+
+// DecodeNew decodes the row after the change into dest.
+// It returns an error for a delete or a truncated change, which carry no new row.
+func (c RowChange) DecodeNew(dest any) error {
+	return decodeRow(c.New, "new", dest)
+}
+
+// decodeRow decodes a JSON row of a RowChange into dest.
+func decodeRow(row json.RawMessage, kind string, dest any) error {
+	if len(row) == 0 || bytes.Equal(row, []byte("null")) {
+		return fmt.Errorf("pgc: change carries no %s row", kind)
+	}
+	return json.Unmarshal(row, dest)
+}
+
+// primaryKeyCols returns the primary key columns of table, using the primary key reported by TableKeys
+// for the quoted table name, so that it designates the relation the watch trigger is installed on.
+func (d *Datasource) primaryKeyCols(ctx context.Context, table string) ([]string, wrapify.R) {
+	defs, response := d.TableKeysCtx(ctx, quoteIdent(table))
+	if response.IsError() {
+		return nil, response
+	}
+	for _, def := range defs {
+		if def.Type == "Primary Key" {
+			return d.constraintCols(ctx, table, def.Name)
+		}
+	}
+	response = wrapify.WrapBadRequest(fmt.Sprintf("Table '%s' has no primary key to identify its changed rows", table), nil).
+		BindCause().
+		Reply()
+	return nil, response
+}
+
+// execScript runs a script of several statements without parameters, such as the DDL installing a watch
+// trigger, as a single implicit transaction. It bypasses the statement cache, since a script cannot be
+// prepared, and is never retried.
+func (d *Datasource) execScript(ctx context.Context, funcName, query string) error {
+	conn := d.Conn()
+	return d.retry(ctx, conn, funcName, false, func() error {
+		// Start inspection
+		done := d.Inspect(funcName, query)
+		_, err := conn.ExecContext(ctx, query)
+		// End inspection
+		done()
+		return err
+	})
+}
+
+// watchChannel returns the notification channel of a watched table: the table name prefixed with
+// watchChannelPrefix, where any character other than a letter, a digit or an underscore is replaced
+// by an underscore. Names exceeding the 63-byte identifier limit are shortened and suffixed with a hash.
+func watchChannel(table string) string {
+	channel := watchChannelPrefix + strings.Map(func(r rune) rune {
+		if 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' || r == '_' {
+			return r
+		}
+		return '_'
+	}, table)
+	if len(channel) <= 63 {
+		return channel
+	}
+	h := fnv.New32a()
+	h.Write([]byte(table))
+	return fmt.Sprintf("%s_%08x", channel[:54], h.Sum32())
+}